@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	sshpkg "github.com/flyingeirc/erban/internal/ssh"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// defaultBroadcastParallel bounds concurrent hosts when the request leaves
+// Parallel unset.
+const defaultBroadcastParallel = 10
+
+// BroadcastRequest describes one command run on many hosts. Sessions are
+// used as they are; profiles get a temporary connection closed afterwards.
+type BroadcastRequest struct {
+	SessionIDs []string `json:"sessionIds"`
+	ProfileIDs []string `json:"profileIds"`
+	Command    string   `json:"command"`
+	Parallel   int      `json:"parallel"`
+	TimeoutMs  int      `json:"timeoutMs"`
+	// Passwd is used for profiles that need a password.
+	Passwd string `json:"passwd"`
+}
+
+// BroadcastOutputEvent is emitted on "ssh:broadcast:output:<broadcastID>"
+// for every output chunk of one host.
+type BroadcastOutputEvent struct {
+	Target string `json:"target"`
+	Stream string `json:"stream"`
+	Data   string `json:"data"`
+}
+
+// BroadcastHostResult is emitted on "ssh:broadcast:host:<broadcastID>" when
+// one host finishes. Target is the session ID or "profile:<id>".
+type BroadcastHostResult struct {
+	Target string             `json:"target"`
+	Host   string             `json:"host"`
+	Result *sshpkg.ExecResult `json:"result,omitempty"`
+	Error  string             `json:"error,omitempty"`
+}
+
+// BroadcastSummary is emitted on "ssh:broadcast:done:<broadcastID>" once
+// every host has finished. A host failed when it could not run the command
+// or exited non-zero.
+type BroadcastSummary struct {
+	Total     int                   `json:"total"`
+	Succeeded int                   `json:"succeeded"`
+	Failed    []string              `json:"failed,omitempty"`
+	Results   []BroadcastHostResult `json:"results"`
+}
+
+// Broadcast runs req.Command on every target concurrently, at most
+// req.Parallel at a time, streaming per-host events keyed by broadcastID.
+// ExecCancel(broadcastID) stops it.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) Broadcast(broadcastID string, req BroadcastRequest) string {
+	if broadcastID == "" {
+		return "broadcast id required"
+	}
+	if req.Command == "" {
+		return "command required"
+	}
+	total := len(req.SessionIDs) + len(req.ProfileIDs)
+	if total == 0 {
+		return "no targets"
+	}
+	parallel := req.Parallel
+	if parallel <= 0 {
+		parallel = defaultBroadcastParallel
+	}
+
+	ctx, cancel := context.WithCancel(b.appContext())
+	b.mu.Lock()
+	if _, busy := b.execs[broadcastID]; busy {
+		b.mu.Unlock()
+		cancel()
+		return fmt.Sprintf("exec %s already running", broadcastID)
+	}
+	if b.execs == nil {
+		b.execs = make(map[string]context.CancelFunc)
+	}
+	b.execs[broadcastID] = cancel
+	b.mu.Unlock()
+
+	go func() {
+		defer func() {
+			b.mu.Lock()
+			delete(b.execs, broadcastID)
+			b.mu.Unlock()
+			cancel()
+		}()
+
+		results := make([]BroadcastHostResult, total)
+		sem := make(chan struct{}, parallel)
+		var wg sync.WaitGroup
+		run := func(i int, target string, open func() (*sshpkg.Sshobject, func(), error)) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i] = BroadcastHostResult{Target: target, Error: ctx.Err().Error()}
+				b.emitBroadcast(broadcastID, "host", results[i])
+				return
+			}
+			defer func() { <-sem }()
+			results[i] = b.broadcastOne(ctx, broadcastID, target, open, req)
+			b.emitBroadcast(broadcastID, "host", results[i])
+		}
+
+		i := 0
+		for _, id := range req.SessionIDs {
+			wg.Add(1)
+			go run(i, id, func() (*sshpkg.Sshobject, func(), error) {
+				obj, err := b.requireSessionObject(id)
+				return obj, func() {}, err
+			})
+			i++
+		}
+		for _, id := range req.ProfileIDs {
+			wg.Add(1)
+			go run(i, "profile:"+id, func() (*sshpkg.Sshobject, func(), error) {
+				return b.broadcastProfileObject(broadcastID, id, req.Passwd)
+			})
+			i++
+		}
+		wg.Wait()
+
+		summary := BroadcastSummary{Total: total, Results: results}
+		for _, r := range results {
+			if r.Error != "" || r.Result == nil || r.Result.ExitStatus != 0 || r.Result.ExitSignal != "" {
+				summary.Failed = append(summary.Failed, r.Target)
+			} else {
+				summary.Succeeded++
+			}
+		}
+		sshpkg.LogInfof("Broadcast %s finished: %d/%d succeeded", broadcastID, summary.Succeeded, total)
+		b.emitBroadcast(broadcastID, "done", summary)
+	}()
+	return ""
+}
+
+// broadcastOne opens one target and runs the command on it.
+func (b *SSHBridge) broadcastOne(ctx context.Context, broadcastID, target string, open func() (*sshpkg.Sshobject, func(), error), req BroadcastRequest) BroadcastHostResult {
+	res := BroadcastHostResult{Target: target}
+	obj, release, err := open()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	defer release()
+	res.Host = obj.Host
+
+	stdout := &broadcastWriter{b: b, id: broadcastID, target: target, stream: "stdout"}
+	stderr := &broadcastWriter{b: b, id: broadcastID, target: target, stream: "stderr"}
+	timeout := time.Duration(req.TimeoutMs) * time.Millisecond
+	res.Result, err = sshpkg.ExecStream(ctx, obj, req.Command, timeout, stdout, stderr)
+	if err != nil {
+		sshpkg.LogErrorf("Broadcast %s on %s failed: %v", broadcastID, target, err)
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// broadcastProfileObject connects a throwaway Sshobject for a profile.
+// Prompts are routed under "<broadcastID>:<profileID>".
+func (b *SSHBridge) broadcastProfileObject(broadcastID, profileID, passwd string) (*sshpkg.Sshobject, func(), error) {
+	p, err := b.profileStore().Get(profileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	promptID := broadcastID + ":" + profileID
+	obj, err := b.buildProfileObject(promptID, p, passwd)
+	if err != nil {
+		return nil, nil, err
+	}
+	obj.R.Disabled = true
+	b.attachPrompts(promptID, obj)
+	if err := sshpkg.CreateClient(obj); err != nil {
+		sshpkg.Close(obj)
+		return nil, nil, err
+	}
+	return obj, func() { sshpkg.Close(obj) }, nil
+}
+
+func (b *SSHBridge) emitBroadcast(broadcastID, kind string, data interface{}) {
+	if b.ctx != nil {
+		runtime.EventsEmit(b.ctx, fmt.Sprintf("ssh:broadcast:%s:%s", kind, broadcastID), data)
+	}
+}
+
+// broadcastWriter emits one output stream of one broadcast host.
+type broadcastWriter struct {
+	b      *SSHBridge
+	id     string
+	target string
+	stream string
+}
+
+func (w *broadcastWriter) Write(p []byte) (int, error) {
+	w.b.emitBroadcast(w.id, "output", BroadcastOutputEvent{Target: w.target, Stream: w.stream, Data: string(p)})
+	return len(p), nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+
+	sshpkg "github.com/flyingeirc/erban/internal/ssh"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ChannelListResult 表示会话附加终端通道列表的返回数据
+type ChannelListResult struct {
+	Channels []sshpkg.ChannelInfo `json:"channels,omitempty"`
+	Error    string               `json:"error,omitempty"`
+}
+
+// channelEventID is the event suffix for a channel of a session.
+func channelEventID(sessionID, channelID string) string {
+	return sessionID + ":" + channelID
+}
+
+// OpenChannel starts another shell on the session's existing connection,
+// e.g. for a split pane. Its output is emitted on
+// "ssh:output:<sessionID>:<channelID>" and its end on
+// "ssh:ended:<sessionID>:<channelID>".
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) OpenChannel(sessionID, channelID string, rows, cols int) string {
+	obj, err := b.requireSessionObject(sessionID)
+	if err != nil {
+		return err.Error()
+	}
+	ew := &eventWriter{ctx: b.ctx, sessionID: channelEventID(sessionID, channelID)}
+	stream, err := sshpkg.OpenChannel(obj, channelID, ew, rows, cols)
+	if err != nil {
+		sshpkg.LogErrorf("Channel open failed (session=%s, channel=%s): %v", sessionID, channelID, err)
+		return err.Error()
+	}
+	go b.watchChannel(sessionID, channelID, obj, stream)
+	return ""
+}
+
+// ChannelWrite forwards raw input to a channel's shell.
+func (b *SSHBridge) ChannelWrite(sessionID, channelID, data string) string {
+	stream, err := b.channelStream(sessionID, channelID)
+	if err != nil {
+		return err.Error()
+	}
+	if _, err := stream.Write([]byte(data)); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// ChannelResize changes a channel's PTY size.
+func (b *SSHBridge) ChannelResize(sessionID, channelID string, rows, cols int) string {
+	stream, err := b.channelStream(sessionID, channelID)
+	if err != nil {
+		return err.Error()
+	}
+	if err := stream.Resize(rows, cols); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// CloseChannel ends a channel's shell; the connection stays open.
+func (b *SSHBridge) CloseChannel(sessionID, channelID string) string {
+	obj, err := b.requireSessionObject(sessionID)
+	if err != nil {
+		return err.Error()
+	}
+	if err := b.endChannel(sessionID, channelID, obj); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// ListChannels returns the session's extra channels.
+func (b *SSHBridge) ListChannels(sessionID string) *ChannelListResult {
+	obj, err := b.requireSessionObject(sessionID)
+	if err != nil {
+		return &ChannelListResult{Error: err.Error()}
+	}
+	return &ChannelListResult{Channels: sshpkg.Channels(obj)}
+}
+
+func (b *SSHBridge) channelStream(sessionID, channelID string) (*sshpkg.StreamSession, error) {
+	obj, err := b.requireSessionObject(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	stream := sshpkg.Channel(obj, channelID)
+	if stream == nil {
+		return nil, fmt.Errorf("channel %s not found", channelID)
+	}
+	return stream, nil
+}
+
+// watchChannel reports the end of a channel's shell. A channel that lost its
+// connection is left registered while the main shell reconnects; it is
+// reopened by reopenChannels.
+func (b *SSHBridge) watchChannel(sessionID, channelID string, obj *sshpkg.Sshobject, stream *sshpkg.StreamSession) {
+	<-stream.Done()
+	if sshpkg.Channel(obj, channelID) != stream {
+		// Closed or replaced by whoever removed it.
+		return
+	}
+	if stream.Dropped() {
+		b.mu.Lock()
+		sess := b.getSessionLocked(sessionID)
+		waiting := !obj.R.Disabled && sess != nil && sess.obj == obj && (sess.ses != nil || sess.reconnectCancel != nil)
+		b.mu.Unlock()
+		if waiting {
+			return
+		}
+	}
+	_ = b.endChannel(sessionID, channelID, obj)
+}
+
+// reopenChannels restarts the channels dropped with the connection, after
+// the main shell came back.
+func (b *SSHBridge) reopenChannels(sessionID string, obj *sshpkg.Sshobject) {
+	reopened, failed := sshpkg.ReopenChannels(obj)
+	for channelID, stream := range reopened {
+		runtime.EventsEmit(b.ctx, fmt.Sprintf("ssh:reconnected:%s", channelEventID(sessionID, channelID)))
+		go b.watchChannel(sessionID, channelID, obj, stream)
+	}
+	for _, channelID := range failed {
+		b.emitChannelEnded(sessionID, channelID)
+	}
+}
+
+// endChannels closes every channel of obj, e.g. when the session closes or
+// reconnecting gave up.
+func (b *SSHBridge) endChannels(sessionID string, obj *sshpkg.Sshobject) {
+	for _, ch := range sshpkg.Channels(obj) {
+		_ = b.endChannel(sessionID, ch.ID, obj)
+	}
+}
+
+// endChannel closes one channel and emits its end. Only the caller that
+// actually removed the channel emits, so the event fires once.
+func (b *SSHBridge) endChannel(sessionID, channelID string, obj *sshpkg.Sshobject) error {
+	if err := sshpkg.CloseChannel(obj, channelID); err != nil {
+		return err
+	}
+	b.emitChannelEnded(sessionID, channelID)
+	return nil
+}
+
+func (b *SSHBridge) emitChannelEnded(sessionID, channelID string) {
+	if b.ctx != nil {
+		runtime.EventsEmit(b.ctx, fmt.Sprintf("ssh:ended:%s", channelEventID(sessionID, channelID)))
+	}
+}
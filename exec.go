@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sshpkg "github.com/flyingeirc/erban/internal/ssh"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ExecResultReply 表示非交互命令执行的返回数据
+type ExecResultReply struct {
+	Result *sshpkg.ExecResult `json:"result,omitempty"`
+	Error  string             `json:"error,omitempty"`
+}
+
+// ExecOutputEvent is emitted on "ssh:exec:output:<execID>" for every chunk
+// a streaming command writes. Stream is "stdout" or "stderr".
+type ExecOutputEvent struct {
+	Stream string `json:"stream"`
+	Data   string `json:"data"`
+}
+
+// Exec runs command on a fresh channel of the session, without touching the
+// interactive shell, and returns its output and exit status.
+// timeoutMs <= 0 means no timeout.
+func (b *SSHBridge) Exec(sessionID, command string, timeoutMs int) *ExecResultReply {
+	obj, err := b.requireSessionObject(sessionID)
+	if err != nil {
+		return &ExecResultReply{Error: err.Error()}
+	}
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	res, err := sshpkg.Exec(b.appContext(), obj, command, timeout)
+	if err != nil {
+		sshpkg.LogErrorf("Exec failed (session=%s): %v", sessionID, err)
+		return &ExecResultReply{Result: res, Error: err.Error()}
+	}
+	return &ExecResultReply{Result: res}
+}
+
+// ExecStream starts command like Exec but emits its output as it arrives on
+// "ssh:exec:output:<execID>" and the final ExecResultReply on
+// "ssh:exec:done:<execID>". execID is chosen by the caller.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) ExecStream(sessionID, execID, command string, timeoutMs int) string {
+	if execID == "" {
+		return "exec id required"
+	}
+	obj, err := b.requireSessionObject(sessionID)
+	if err != nil {
+		return err.Error()
+	}
+	ctx, cancel := context.WithCancel(b.appContext())
+	b.mu.Lock()
+	if _, busy := b.execs[execID]; busy {
+		b.mu.Unlock()
+		cancel()
+		return fmt.Sprintf("exec %s already running", execID)
+	}
+	if b.execs == nil {
+		b.execs = make(map[string]context.CancelFunc)
+	}
+	b.execs[execID] = cancel
+	b.mu.Unlock()
+
+	go func() {
+		defer func() {
+			b.mu.Lock()
+			delete(b.execs, execID)
+			b.mu.Unlock()
+			cancel()
+		}()
+		event := fmt.Sprintf("ssh:exec:output:%s", execID)
+		stdout := &execEventWriter{ctx: b.ctx, event: event, stream: "stdout"}
+		stderr := &execEventWriter{ctx: b.ctx, event: event, stream: "stderr"}
+		timeout := time.Duration(timeoutMs) * time.Millisecond
+		res, err := sshpkg.ExecStream(ctx, obj, command, timeout, stdout, stderr)
+		reply := &ExecResultReply{Result: res}
+		if err != nil {
+			sshpkg.LogErrorf("Exec failed (session=%s, exec=%s): %v", sessionID, execID, err)
+			reply.Error = err.Error()
+		}
+		if b.ctx != nil {
+			runtime.EventsEmit(b.ctx, fmt.Sprintf("ssh:exec:done:%s", execID), reply)
+		}
+	}()
+	return ""
+}
+
+// ExecCancel stops a command started with ExecStream.
+func (b *SSHBridge) ExecCancel(execID string) string {
+	b.mu.Lock()
+	cancel := b.execs[execID]
+	b.mu.Unlock()
+	if cancel == nil {
+		return "exec not running"
+	}
+	cancel()
+	return ""
+}
+
+// execEventWriter emits one output stream of a command to the frontend.
+type execEventWriter struct {
+	ctx    context.Context
+	event  string
+	stream string
+}
+
+func (w *execEventWriter) Write(p []byte) (int, error) {
+	if w.ctx == nil {
+		return len(p), nil
+	}
+	runtime.EventsEmit(w.ctx, w.event, ExecOutputEvent{Stream: w.stream, Data: string(p)})
+	return len(p), nil
+}
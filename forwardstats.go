@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	sshpkg "github.com/flyingeirc/erban/internal/ssh"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ForwardStatsItem is one forward with its traffic counters.
+type ForwardStatsItem struct {
+	ID   string `json:"id"`
+	Mode string `json:"mode"`
+	From string `json:"from"`
+	To   string `json:"to,omitempty"`
+	sshpkg.ForwardStats
+}
+
+// ForwardStatsResult 表示端口转发流量统计的返回数据
+type ForwardStatsResult struct {
+	Forwards []ForwardStatsItem `json:"forwards,omitempty"`
+	Error    string             `json:"error,omitempty"`
+}
+
+// ForwardStats returns bytes, connection counts, the last error and the
+// active connections of every forward of the session.
+func (b *SSHBridge) ForwardStats(sessionID string) *ForwardStatsResult {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sess := b.getSessionLocked(sessionID)
+	if sess == nil {
+		return &ForwardStatsResult{Error: "session not found"}
+	}
+	return &ForwardStatsResult{Forwards: forwardStatsLocked(sess)}
+}
+
+// StartForwardStats emits the ForwardStats items on
+// "ssh:fwdstats:<sessionID>" every intervalMs (default 1000).
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) StartForwardStats(sessionID string, intervalMs int) string {
+	if intervalMs <= 0 {
+		intervalMs = 1000
+	}
+	b.mu.Lock()
+	sess := b.getSessionLocked(sessionID)
+	if sess == nil {
+		b.mu.Unlock()
+		return "session not found"
+	}
+	if sess.fwdStats != nil {
+		sess.fwdStats()
+	}
+	ctx, cancel := context.WithCancel(b.appContext())
+	sess.fwdStats = cancel
+	b.mu.Unlock()
+
+	event := fmt.Sprintf("ssh:fwdstats:%s", sessionID)
+	go func() {
+		t := time.NewTicker(time.Duration(intervalMs) * time.Millisecond)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			b.mu.Lock()
+			s := b.getSessionLocked(sessionID)
+			if s == nil {
+				b.mu.Unlock()
+				return
+			}
+			items := forwardStatsLocked(s)
+			b.mu.Unlock()
+			if b.ctx != nil {
+				runtime.EventsEmit(b.ctx, event, items)
+			}
+		}
+	}()
+	return ""
+}
+
+// StopForwardStats stops the session's "ssh:fwdstats" events.
+func (b *SSHBridge) StopForwardStats(sessionID string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if sess := b.getSessionLocked(sessionID); sess != nil && sess.fwdStats != nil {
+		sess.fwdStats()
+		sess.fwdStats = nil
+	}
+	return ""
+}
+
+func forwardStatsLocked(sess *sessionState) []ForwardStatsItem {
+	out := make([]ForwardStatsItem, 0, len(sess.fwd))
+	for _, h := range sess.fwd {
+		if h == nil {
+			continue
+		}
+		item := ForwardStatsItem{ID: h.id, Mode: h.mode, From: h.from, To: h.to}
+		if h.stats != nil {
+			item.ForwardStats = h.stats()
+		}
+		out = append(out, item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
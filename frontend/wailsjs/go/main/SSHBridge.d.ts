@@ -1,25 +1,99 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
+import {ssh} from '../models';
+import {store} from '../models';
+
+export function AddJumpWithAgent(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function AddJumpWithPasswd(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
+export function AddJumpWithPem(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
+export function Broadcast(arg1:string,arg2:main.BroadcastRequest):Promise<string>;
+
+export function ChangeMasterPassphrase(arg1:string,arg2:string):Promise<string>;
+
+export function ChannelResize(arg1:string,arg2:string,arg3:number,arg4:number):Promise<string>;
+
+export function ChannelWrite(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function ClearJumps(arg1:string):Promise<string>;
 
 export function Close(arg1:string):Promise<void>;
 
+export function CloseChannel(arg1:string,arg2:string):Promise<string>;
+
 export function Connect(arg1:string):Promise<string>;
 
+export function ConnectWithOptions(arg1:string,arg2:ssh.PtyOptions):Promise<string>;
+
 export function CreateClient(arg1:string):Promise<string>;
 
+export function Exec(arg1:string,arg2:string,arg3:number):Promise<main.ExecResultReply>;
+
+export function ExecCancel(arg1:string):Promise<string>;
+
+export function ExecStream(arg1:string,arg2:string,arg3:string,arg4:number):Promise<string>;
+
+export function ExportTranscript(arg1:string,arg2:string):Promise<main.TranscriptExportResult>;
+
+export function ForwardStats(arg1:string):Promise<main.ForwardStatsResult>;
+
+export function GetScrollback(arg1:string,arg2:number,arg3:number):Promise<main.ScrollbackResult>;
+
+export function HostKeyResponse(arg1:string,arg2:boolean):Promise<string>;
+
+export function InitFromProfile(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function InitFromSSHConfig(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
+export function InitWithAgent(arg1:string,arg2:string,arg3:string):Promise<string>;
+
 export function InitWithPasswd(arg1:string,arg2:string,arg3:string,arg4:string):Promise<void>;
 
-export function InitWithPem(arg1:string,arg2:string,arg3:string,arg4:string):Promise<void>;
+export function InitWithPem(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
 
 export function KeyDelete(arg1:string):Promise<string>;
 
 export function KeyGet(arg1:string):Promise<string>;
 
+export function KeyList():Promise<main.KeyListResult>;
+
 export function KeyPut(arg1:string,arg2:string):Promise<string>;
 
+export function KeyboardInteractiveResponse(arg1:string,arg2:Array<string>,arg3:boolean):Promise<string>;
+
+export function KeystoreState():Promise<main.KeystoreStateResult>;
+
+export function KillProcess(arg1:string,arg2:number,arg3:string):Promise<string>;
+
+export function ListChannels(arg1:string):Promise<main.ChannelListResult>;
+
 export function ListForwards(arg1:string):Promise<string>;
 
+export function ListProcesses(arg1:string,arg2:string,arg3:boolean,arg4:string,arg5:number):Promise<main.ProcessListResult>;
+
+export function ListRecordings():Promise<main.RecordingListResult>;
+
+export function LockKeystore():Promise<void>;
+
+export function OpenChannel(arg1:string,arg2:string,arg3:number,arg4:number):Promise<string>;
+
+export function PassphraseResponse(arg1:string,arg2:string,arg3:boolean):Promise<string>;
+
+export function PlayRecording(arg1:string,arg2:string,arg3:number,arg4:number):Promise<string>;
+
+export function ProfileDelete(arg1:string):Promise<string>;
+
+export function ProfileGet(arg1:string):Promise<main.ProfileResult>;
+
+export function ProfileList():Promise<main.ProfileListResult>;
+
+export function ProfileSave(arg1:store.Profile):Promise<main.ProfileResult>;
+
+export function RecordingDir():Promise<string>;
+
 export function Resize(arg1:string,arg2:number,arg3:number):Promise<string>;
 
 export function SFTPDownload(arg1:string,arg2:string):Promise<main.SFTPDownloadResult>;
@@ -30,18 +104,66 @@ export function SFTPResumeUpload(arg1:string,arg2:string,arg3:Array<number>):Pro
 
 export function SFTPUpload(arg1:string,arg2:string,arg3:Array<number>):Promise<string>;
 
+export function SSHConfigHosts(arg1:string):Promise<main.SSHConfigResult>;
+
+export function SearchTranscript(arg1:string,arg2:string):Promise<main.TranscriptSearchResult>;
+
 export function Send(arg1:string,arg2:string):Promise<string>;
 
+export function SessionInfo(arg1:string):Promise<main.SessionInfoResult>;
+
+export function SetAgentForwarding(arg1:string,arg2:boolean):Promise<string>;
+
+export function SetCertificate(arg1:string,arg2:string):Promise<string>;
+
+export function SetKeepalive(arg1:string,arg2:number,arg3:number):Promise<string>;
+
+export function SetKeystoreAutoLock(arg1:number):Promise<void>;
+
+export function SetMultiplex(arg1:string,arg2:boolean):Promise<string>;
+
+export function SetPassword(arg1:string,arg2:string):Promise<string>;
+
 export function SetProxy(arg1:string,arg2:string):Promise<string>;
 
+export function SetReconnectPolicy(arg1:string,arg2:number,arg3:number,arg4:number):Promise<string>;
+
+export function SetRecordingDir(arg1:string):Promise<string>;
+
+export function SetX11Forwarding(arg1:string,arg2:boolean):Promise<string>;
+
 export function StartDynamicForward(arg1:string,arg2:string):Promise<string>;
 
+export function StartDynamicForwardWithOptions(arg1:string,arg2:string,arg3:ssh.SocksOptions):Promise<string>;
+
+export function StartForwardStats(arg1:string,arg2:number):Promise<string>;
+
+export function StartHTTPProxyForward(arg1:string,arg2:string,arg3:ssh.SocksOptions):Promise<string>;
+
 export function StartLocalForward(arg1:string,arg2:string,arg3:string):Promise<string>;
 
+export function StartLocalStreamForward(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function StartMonitor(arg1:string,arg2:number):Promise<string>;
+
+export function StartRecording(arg1:string):Promise<main.RecordingResult>;
+
 export function StartRemoteForward(arg1:string,arg2:string,arg3:string):Promise<string>;
 
+export function StartRemoteStreamForward(arg1:string,arg2:string,arg3:string):Promise<string>;
+
 export function StopAllForwards(arg1:string):Promise<string>;
 
 export function StopForward(arg1:string,arg2:string):Promise<string>;
 
+export function StopForwardStats(arg1:string):Promise<string>;
+
+export function StopMonitor(arg1:string):Promise<string>;
+
+export function StopPlayback(arg1:string):Promise<string>;
+
+export function StopRecording(arg1:string):Promise<main.RecordingResult>;
+
+export function UnlockKeystore(arg1:string):Promise<string>;
+
 export function Write(arg1:string,arg2:string):Promise<string>;
@@ -2,18 +2,98 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddJumpWithAgent(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['AddJumpWithAgent'](arg1, arg2, arg3);
+}
+
+export function AddJumpWithPasswd(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['SSHBridge']['AddJumpWithPasswd'](arg1, arg2, arg3, arg4);
+}
+
+export function AddJumpWithPem(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['SSHBridge']['AddJumpWithPem'](arg1, arg2, arg3, arg4);
+}
+
+export function Broadcast(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['Broadcast'](arg1, arg2);
+}
+
+export function ChangeMasterPassphrase(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['ChangeMasterPassphrase'](arg1, arg2);
+}
+
+export function ChannelResize(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['SSHBridge']['ChannelResize'](arg1, arg2, arg3, arg4);
+}
+
+export function ChannelWrite(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['ChannelWrite'](arg1, arg2, arg3);
+}
+
+export function ClearJumps(arg1) {
+  return window['go']['main']['SSHBridge']['ClearJumps'](arg1);
+}
+
 export function Close(arg1) {
   return window['go']['main']['SSHBridge']['Close'](arg1);
 }
 
+export function CloseChannel(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['CloseChannel'](arg1, arg2);
+}
+
 export function Connect(arg1) {
   return window['go']['main']['SSHBridge']['Connect'](arg1);
 }
 
+export function ConnectWithOptions(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['ConnectWithOptions'](arg1, arg2);
+}
+
 export function CreateClient(arg1) {
   return window['go']['main']['SSHBridge']['CreateClient'](arg1);
 }
 
+export function Exec(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['Exec'](arg1, arg2, arg3);
+}
+
+export function ExecCancel(arg1) {
+  return window['go']['main']['SSHBridge']['ExecCancel'](arg1);
+}
+
+export function ExecStream(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['SSHBridge']['ExecStream'](arg1, arg2, arg3, arg4);
+}
+
+export function ExportTranscript(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['ExportTranscript'](arg1, arg2);
+}
+
+export function ForwardStats(arg1) {
+  return window['go']['main']['SSHBridge']['ForwardStats'](arg1);
+}
+
+export function GetScrollback(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['GetScrollback'](arg1, arg2, arg3);
+}
+
+export function HostKeyResponse(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['HostKeyResponse'](arg1, arg2);
+}
+
+export function InitFromProfile(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['InitFromProfile'](arg1, arg2, arg3);
+}
+
+export function InitFromSSHConfig(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['SSHBridge']['InitFromSSHConfig'](arg1, arg2, arg3, arg4);
+}
+
+export function InitWithAgent(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['InitWithAgent'](arg1, arg2, arg3);
+}
+
 export function InitWithPasswd(arg1, arg2, arg3, arg4) {
   return window['go']['main']['SSHBridge']['InitWithPasswd'](arg1, arg2, arg3, arg4);
 }
@@ -30,14 +110,78 @@ export function KeyGet(arg1) {
   return window['go']['main']['SSHBridge']['KeyGet'](arg1);
 }
 
+export function KeyList() {
+  return window['go']['main']['SSHBridge']['KeyList']();
+}
+
 export function KeyPut(arg1, arg2) {
   return window['go']['main']['SSHBridge']['KeyPut'](arg1, arg2);
 }
 
+export function KeyboardInteractiveResponse(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['KeyboardInteractiveResponse'](arg1, arg2, arg3);
+}
+
+export function KeystoreState() {
+  return window['go']['main']['SSHBridge']['KeystoreState']();
+}
+
+export function KillProcess(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['KillProcess'](arg1, arg2, arg3);
+}
+
+export function ListChannels(arg1) {
+  return window['go']['main']['SSHBridge']['ListChannels'](arg1);
+}
+
 export function ListForwards(arg1) {
   return window['go']['main']['SSHBridge']['ListForwards'](arg1);
 }
 
+export function ListProcesses(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['SSHBridge']['ListProcesses'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function ListRecordings() {
+  return window['go']['main']['SSHBridge']['ListRecordings']();
+}
+
+export function LockKeystore() {
+  return window['go']['main']['SSHBridge']['LockKeystore']();
+}
+
+export function OpenChannel(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['SSHBridge']['OpenChannel'](arg1, arg2, arg3, arg4);
+}
+
+export function PassphraseResponse(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['PassphraseResponse'](arg1, arg2, arg3);
+}
+
+export function PlayRecording(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['SSHBridge']['PlayRecording'](arg1, arg2, arg3, arg4);
+}
+
+export function ProfileDelete(arg1) {
+  return window['go']['main']['SSHBridge']['ProfileDelete'](arg1);
+}
+
+export function ProfileGet(arg1) {
+  return window['go']['main']['SSHBridge']['ProfileGet'](arg1);
+}
+
+export function ProfileList() {
+  return window['go']['main']['SSHBridge']['ProfileList']();
+}
+
+export function ProfileSave(arg1) {
+  return window['go']['main']['SSHBridge']['ProfileSave'](arg1);
+}
+
+export function RecordingDir() {
+  return window['go']['main']['SSHBridge']['RecordingDir']();
+}
+
 export function Resize(arg1, arg2, arg3) {
   return window['go']['main']['SSHBridge']['Resize'](arg1, arg2, arg3);
 }
@@ -58,26 +202,102 @@ export function SFTPUpload(arg1, arg2, arg3) {
   return window['go']['main']['SSHBridge']['SFTPUpload'](arg1, arg2, arg3);
 }
 
+export function SSHConfigHosts(arg1) {
+  return window['go']['main']['SSHBridge']['SSHConfigHosts'](arg1);
+}
+
+export function SearchTranscript(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['SearchTranscript'](arg1, arg2);
+}
+
 export function Send(arg1, arg2) {
   return window['go']['main']['SSHBridge']['Send'](arg1, arg2);
 }
 
+export function SessionInfo(arg1) {
+  return window['go']['main']['SSHBridge']['SessionInfo'](arg1);
+}
+
+export function SetAgentForwarding(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['SetAgentForwarding'](arg1, arg2);
+}
+
+export function SetCertificate(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['SetCertificate'](arg1, arg2);
+}
+
+export function SetKeepalive(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['SetKeepalive'](arg1, arg2, arg3);
+}
+
+export function SetKeystoreAutoLock(arg1) {
+  return window['go']['main']['SSHBridge']['SetKeystoreAutoLock'](arg1);
+}
+
+export function SetMultiplex(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['SetMultiplex'](arg1, arg2);
+}
+
+export function SetPassword(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['SetPassword'](arg1, arg2);
+}
+
 export function SetProxy(arg1, arg2) {
   return window['go']['main']['SSHBridge']['SetProxy'](arg1, arg2);
 }
 
+export function SetReconnectPolicy(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['SSHBridge']['SetReconnectPolicy'](arg1, arg2, arg3, arg4);
+}
+
+export function SetRecordingDir(arg1) {
+  return window['go']['main']['SSHBridge']['SetRecordingDir'](arg1);
+}
+
+export function SetX11Forwarding(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['SetX11Forwarding'](arg1, arg2);
+}
+
 export function StartDynamicForward(arg1, arg2) {
   return window['go']['main']['SSHBridge']['StartDynamicForward'](arg1, arg2);
 }
 
+export function StartDynamicForwardWithOptions(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['StartDynamicForwardWithOptions'](arg1, arg2, arg3);
+}
+
+export function StartForwardStats(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['StartForwardStats'](arg1, arg2);
+}
+
+export function StartHTTPProxyForward(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['StartHTTPProxyForward'](arg1, arg2, arg3);
+}
+
 export function StartLocalForward(arg1, arg2, arg3) {
   return window['go']['main']['SSHBridge']['StartLocalForward'](arg1, arg2, arg3);
 }
 
+export function StartLocalStreamForward(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['StartLocalStreamForward'](arg1, arg2, arg3);
+}
+
+export function StartMonitor(arg1, arg2) {
+  return window['go']['main']['SSHBridge']['StartMonitor'](arg1, arg2);
+}
+
+export function StartRecording(arg1) {
+  return window['go']['main']['SSHBridge']['StartRecording'](arg1);
+}
+
 export function StartRemoteForward(arg1, arg2, arg3) {
   return window['go']['main']['SSHBridge']['StartRemoteForward'](arg1, arg2, arg3);
 }
 
+export function StartRemoteStreamForward(arg1, arg2, arg3) {
+  return window['go']['main']['SSHBridge']['StartRemoteStreamForward'](arg1, arg2, arg3);
+}
+
 export function StopAllForwards(arg1) {
   return window['go']['main']['SSHBridge']['StopAllForwards'](arg1);
 }
@@ -86,6 +306,26 @@ export function StopForward(arg1, arg2) {
   return window['go']['main']['SSHBridge']['StopForward'](arg1, arg2);
 }
 
+export function StopForwardStats(arg1) {
+  return window['go']['main']['SSHBridge']['StopForwardStats'](arg1);
+}
+
+export function StopMonitor(arg1) {
+  return window['go']['main']['SSHBridge']['StopMonitor'](arg1);
+}
+
+export function StopPlayback(arg1) {
+  return window['go']['main']['SSHBridge']['StopPlayback'](arg1);
+}
+
+export function StopRecording(arg1) {
+  return window['go']['main']['SSHBridge']['StopRecording'](arg1);
+}
+
+export function UnlockKeystore(arg1) {
+  return window['go']['main']['SSHBridge']['UnlockKeystore'](arg1);
+}
+
 export function Write(arg1, arg2) {
   return window['go']['main']['SSHBridge']['Write'](arg1, arg2);
 }
@@ -1,30 +1,566 @@
 export namespace main {
 	
+	export class BroadcastRequest {
+	    sessionIds: string[];
+	    profileIds: string[];
+	    command: string;
+	    parallel: number;
+	    timeoutMs: number;
+	    passwd: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BroadcastRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sessionIds = source["sessionIds"];
+	        this.profileIds = source["profileIds"];
+	        this.command = source["command"];
+	        this.parallel = source["parallel"];
+	        this.timeoutMs = source["timeoutMs"];
+	        this.passwd = source["passwd"];
+	    }
+	}
+	export class ChannelListResult {
+	    channels?: ssh.ChannelInfo[];
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChannelListResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channels = this.convertValues(source["channels"], ssh.ChannelInfo);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ExecResultReply {
+	    result?: ssh.ExecResult;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExecResultReply(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.result = this.convertValues(source["result"], ssh.ExecResult);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ForwardStatsItem {
+	    id: string;
+	    mode: string;
+	    from: string;
+	    to?: string;
+	    bytesIn: number;
+	    bytesOut: number;
+	    active: number;
+	    total: number;
+	    lastError?: string;
+	    // Go type: time
+	    lastErrorAt?: any;
+	    conns?: ssh.ForwardConnStats[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ForwardStatsItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.mode = source["mode"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.bytesIn = source["bytesIn"];
+	        this.bytesOut = source["bytesOut"];
+	        this.active = source["active"];
+	        this.total = source["total"];
+	        this.lastError = source["lastError"];
+	        this.lastErrorAt = this.convertValues(source["lastErrorAt"], null);
+	        this.conns = this.convertValues(source["conns"], ssh.ForwardConnStats);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ForwardStatsResult {
+	    forwards?: ForwardStatsItem[];
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ForwardStatsResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.forwards = this.convertValues(source["forwards"], ForwardStatsItem);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class KeyListResult {
+	    names?: string[];
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new KeyListResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.names = source["names"];
+	        this.error = source["error"];
+	    }
+	}
+	export class KeystoreStateResult {
+	    state?: store.KeystoreState;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new KeystoreStateResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.state = this.convertValues(source["state"], store.KeystoreState);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProcessListResult {
+	    processes?: ssh.Process[];
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProcessListResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.processes = this.convertValues(source["processes"], ssh.Process);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProfileListResult {
+	    profiles?: store.Profile[];
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProfileListResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.profiles = this.convertValues(source["profiles"], store.Profile);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProfileResult {
+	    profile?: store.Profile;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProfileResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.profile = this.convertValues(source["profile"], store.Profile);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RecordingInfo {
+	    name: string;
+	    path: string;
+	    size: number;
+	    // Go type: time
+	    modTime: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordingInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.size = source["size"];
+	        this.modTime = this.convertValues(source["modTime"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RecordingListResult {
+	    recordings?: RecordingInfo[];
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordingListResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.recordings = this.convertValues(source["recordings"], RecordingInfo);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RecordingResult {
+	    path?: string;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordingResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.error = source["error"];
+	    }
+	}
 	export class SFTPDownloadResult {
 	    data?: number[];
 	    error?: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new SFTPDownloadResult(source);
+	        return new SFTPDownloadResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.data = source["data"];
+	        this.error = source["error"];
+	    }
+	}
+	export class SFTPListResult {
+	    entries?: ssh.SFTPEntry[];
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SFTPListResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entries = this.convertValues(source["entries"], ssh.SFTPEntry);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SSHConfigResult {
+	    hosts?: ssh.HostProfile[];
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SSHConfigResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hosts = this.convertValues(source["hosts"], ssh.HostProfile);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ScrollbackResult {
+	    page?: ssh.ScrollbackPage;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScrollbackResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.page = this.convertValues(source["page"], ssh.ScrollbackPage);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SessionInfoResult {
+	    info?: ssh.SessionInfo;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionInfoResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.info = this.convertValues(source["info"], ssh.SessionInfo);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TranscriptExportResult {
+	    content?: string;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TranscriptExportResult(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.data = source["data"];
+	        this.content = source["content"];
 	        this.error = source["error"];
 	    }
 	}
-	export class SFTPListResult {
-	    entries?: ssh.SFTPEntry[];
+	export class TranscriptSearchResult {
+	    matches?: ssh.TranscriptMatch[];
 	    error?: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new SFTPListResult(source);
+	        return new TranscriptSearchResult(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.entries = this.convertValues(source["entries"], ssh.SFTPEntry);
+	        this.matches = this.convertValues(source["matches"], ssh.TranscriptMatch);
 	        this.error = source["error"];
 	    }
 	
@@ -51,6 +587,212 @@ export namespace main {
 
 export namespace ssh {
 	
+	export class CertInfo {
+	    keyId: string;
+	    serial: number;
+	    principals?: string[];
+	    ca: string;
+	    // Go type: time
+	    validAfter: any;
+	    // Go type: time
+	    validBefore: any;
+	    expired: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new CertInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.keyId = source["keyId"];
+	        this.serial = source["serial"];
+	        this.principals = source["principals"];
+	        this.ca = source["ca"];
+	        this.validAfter = this.convertValues(source["validAfter"], null);
+	        this.validBefore = this.convertValues(source["validBefore"], null);
+	        this.expired = source["expired"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ChannelInfo {
+	    id: string;
+	    rows: number;
+	    cols: number;
+	    open: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChannelInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.rows = source["rows"];
+	        this.cols = source["cols"];
+	        this.open = source["open"];
+	    }
+	}
+	export class ExecResult {
+	    stdout: string;
+	    stderr: string;
+	    exitStatus: number;
+	    exitSignal?: string;
+	    duration: number;
+	    timedOut?: boolean;
+	    canceled?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExecResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.stdout = source["stdout"];
+	        this.stderr = source["stderr"];
+	        this.exitStatus = source["exitStatus"];
+	        this.exitSignal = source["exitSignal"];
+	        this.duration = source["duration"];
+	        this.timedOut = source["timedOut"];
+	        this.canceled = source["canceled"];
+	    }
+	}
+	export class ForwardSpec {
+	    bind: string;
+	    target: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ForwardSpec(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.bind = source["bind"];
+	        this.target = source["target"];
+	    }
+	}
+	export class HostProfile {
+	    alias: string;
+	    hostName: string;
+	    port: number;
+	    user?: string;
+	    identityFiles?: string[];
+	    certificateFiles?: string[];
+	    proxyJump?: string[];
+	    jumps?: HostProfile[];
+	    localForwards?: ForwardSpec[];
+	    remoteForwards?: ForwardSpec[];
+	    dynamicForwards?: string[];
+	    forwardAgent?: boolean;
+	    serverAliveInterval?: number;
+	    serverAliveCountMax?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HostProfile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.alias = source["alias"];
+	        this.hostName = source["hostName"];
+	        this.port = source["port"];
+	        this.user = source["user"];
+	        this.identityFiles = source["identityFiles"];
+	        this.certificateFiles = source["certificateFiles"];
+	        this.proxyJump = source["proxyJump"];
+	        this.jumps = this.convertValues(source["jumps"], HostProfile);
+	        this.localForwards = this.convertValues(source["localForwards"], ForwardSpec);
+	        this.remoteForwards = this.convertValues(source["remoteForwards"], ForwardSpec);
+	        this.dynamicForwards = source["dynamicForwards"];
+	        this.forwardAgent = source["forwardAgent"];
+	        this.serverAliveInterval = source["serverAliveInterval"];
+	        this.serverAliveCountMax = source["serverAliveCountMax"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Process {
+	    pid: number;
+	    ppid: number;
+	    user: string;
+	    cpu: number;
+	    mem: number;
+	    rss: number;
+	    state: string;
+	    elapsed: string;
+	    command: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Process(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pid = source["pid"];
+	        this.ppid = source["ppid"];
+	        this.user = source["user"];
+	        this.cpu = source["cpu"];
+	        this.mem = source["mem"];
+	        this.rss = source["rss"];
+	        this.state = source["state"];
+	        this.elapsed = source["elapsed"];
+	        this.command = source["command"];
+	    }
+	}
+	export class PtyOptions {
+	    term?: string;
+	    modes?: Record<string, number>;
+	    env?: Record<string, string>;
+	    command?: string;
+	    subsystem?: string;
+	    loginShell?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PtyOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.term = source["term"];
+	        this.modes = source["modes"];
+	        this.env = source["env"];
+	        this.command = source["command"];
+	        this.subsystem = source["subsystem"];
+	        this.loginShell = source["loginShell"];
+	    }
+	}
 	export class SFTPEntry {
 	    name: string;
 	    size: number;
@@ -90,6 +832,295 @@ export namespace ssh {
 		    return a;
 		}
 	}
+	export class ScrollbackLine {
+	    n: number;
+	    text: string;
+	    raw: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScrollbackLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.n = source["n"];
+	        this.text = source["text"];
+	        this.raw = source["raw"];
+	    }
+	}
+	export class ScrollbackPage {
+	    lines: ScrollbackLine[];
+	    first: number;
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScrollbackPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.lines = this.convertValues(source["lines"], ScrollbackLine);
+	        this.first = source["first"];
+	        this.total = source["total"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SessionInfo {
+	    host: string;
+	    user: string;
+	    label?: string;
+	    auth: string;
+	    connected: boolean;
+	    proxy?: string;
+	    jumps?: string[];
+	    forwardAgent: boolean;
+	    forwardX11: boolean;
+	    cert?: CertInfo;
+	    hostCertAuthority?: string;
+	    latencyMs?: number;
+	    sharedRefs?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.user = source["user"];
+	        this.label = source["label"];
+	        this.auth = source["auth"];
+	        this.connected = source["connected"];
+	        this.proxy = source["proxy"];
+	        this.jumps = source["jumps"];
+	        this.forwardAgent = source["forwardAgent"];
+	        this.forwardX11 = source["forwardX11"];
+	        this.cert = this.convertValues(source["cert"], CertInfo);
+	        this.hostCertAuthority = source["hostCertAuthority"];
+	        this.latencyMs = source["latencyMs"];
+	        this.sharedRefs = source["sharedRefs"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SocksOptions {
+	    username?: string;
+	    password?: string;
+	    udpHelper?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SocksOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.password = source["password"];
+	        this.udpHelper = source["udpHelper"];
+	    }
+	}
+	export class TranscriptMatch {
+	    n: number;
+	    text: string;
+	    start: number;
+	    end: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TranscriptMatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.n = source["n"];
+	        this.text = source["text"];
+	        this.start = source["start"];
+	        this.end = source["end"];
+	    }
+	}
+
+}
+
+export namespace store {
+	
+	export class Forward {
+	    mode: string;
+	    from: string;
+	    to?: string;
+	    username?: string;
+	    passwordRef?: string;
+	    udpHelper?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Forward(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.username = source["username"];
+	        this.passwordRef = source["passwordRef"];
+	        this.udpHelper = source["udpHelper"];
+	    }
+	}
+	export class JumpHost {
+	    host: string;
+	    port?: number;
+	    user: string;
+	    auth: string;
+	    keyRef?: string;
+	    certRef?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new JumpHost(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.user = source["user"];
+	        this.auth = source["auth"];
+	        this.keyRef = source["keyRef"];
+	        this.certRef = source["certRef"];
+	    }
+	}
+	export class KeystoreState {
+	    encrypted: boolean;
+	    locked: boolean;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new KeystoreState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.encrypted = source["encrypted"];
+	        this.locked = source["locked"];
+	        this.count = source["count"];
+	    }
+	}
+	export class Profile {
+	    id: string;
+	    name: string;
+	    host: string;
+	    port?: number;
+	    user: string;
+	    auth: string;
+	    keyRef?: string;
+	    proxyUrl?: string;
+	    jumps?: JumpHost[];
+	    forwards?: Forward[];
+	    label?: string;
+	    groups?: string[];
+	    rows?: number;
+	    cols?: number;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    updatedAt: any;
+	    certRef?: string;
+	    forwardAgent?: boolean;
+	    forwardX11?: boolean;
+	    reconnectAttempts?: number;
+	    keepaliveInterval?: number;
+	    keepaliveCountMax?: number;
+	    multiplex?: boolean;
+	    term?: string;
+	    termModes?: Record<string, number>;
+	    env?: Record<string, string>;
+	    startupCommand?: string;
+	    subsystem?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Profile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.user = source["user"];
+	        this.auth = source["auth"];
+	        this.keyRef = source["keyRef"];
+	        this.proxyUrl = source["proxyUrl"];
+	        this.jumps = this.convertValues(source["jumps"], JumpHost);
+	        this.forwards = this.convertValues(source["forwards"], Forward);
+	        this.label = source["label"];
+	        this.groups = source["groups"];
+	        this.rows = source["rows"];
+	        this.cols = source["cols"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	        this.certRef = source["certRef"];
+	        this.forwardAgent = source["forwardAgent"];
+	        this.forwardX11 = source["forwardX11"];
+	        this.reconnectAttempts = source["reconnectAttempts"];
+	        this.keepaliveInterval = source["keepaliveInterval"];
+	        this.keepaliveCountMax = source["keepaliveCountMax"];
+	        this.multiplex = source["multiplex"];
+	        this.term = source["term"];
+	        this.termModes = source["termModes"];
+	        this.env = source["env"];
+	        this.startupCommand = source["startupCommand"];
+	        this.subsystem = source["subsystem"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
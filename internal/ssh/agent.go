@@ -0,0 +1,138 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// agentConn is the lazily dialed connection to the local ssh-agent.
+type agentConn struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	client agent.ExtendedAgent
+}
+
+// AgentSocket returns the ssh-agent socket from SSH_AUTH_SOCK.
+func AgentSocket() string {
+	return strings.TrimSpace(os.Getenv("SSH_AUTH_SOCK"))
+}
+
+// AgentAvailable reports whether an ssh-agent is reachable.
+func AgentAvailable() bool {
+	sock := AgentSocket()
+	if sock == "" {
+		return false
+	}
+	c, err := net.DialTimeout("unix", sock, 2*time.Second)
+	if err != nil {
+		return false
+	}
+	_ = c.Close()
+	return true
+}
+
+// signers returns the agent's keys, dialing the agent on first use or after
+// the previous connection was closed.
+func (a *agentConn) signers() ([]ssh.Signer, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.client == nil {
+		sock := AgentSocket()
+		if sock == "" {
+			return nil, fmt.Errorf("SSH_AUTH_SOCK is not set")
+		}
+		c, err := net.DialTimeout("unix", sock, 5*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("ssh-agent connect failed: %v", err)
+		}
+		a.conn, a.client = c, agent.NewClient(c)
+	}
+	signers, err := a.client.Signers()
+	if err != nil {
+		a.closeLocked()
+		return nil, fmt.Errorf("ssh-agent list keys failed: %v", err)
+	}
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("ssh-agent has no keys")
+	}
+	return signers, nil
+}
+
+func (a *agentConn) close() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	a.closeLocked()
+	a.mu.Unlock()
+}
+
+func (a *agentConn) closeLocked() {
+	if a.conn != nil {
+		_ = a.conn.Close()
+	}
+	a.conn, a.client = nil, nil
+}
+
+// InitWithAgent creates an Sshobject that authenticates with the keys held
+// by the ssh-agent at SSH_AUTH_SOCK.
+func InitWithAgent(host, user string) (*Sshobject, error) {
+	if AgentSocket() == "" {
+		return nil, fmt.Errorf("SSH_AUTH_SOCK is not set")
+	}
+	obj := &Sshobject{
+		Host:  host,
+		User:  user,
+		auth:  AuthAgent,
+		agent: &agentConn{},
+	}
+	obj.config = &ssh.ClientConfig{
+		Timeout:         30 * time.Second,
+		User:            user,
+		Auth:            obj.authMethods(ssh.PublicKeysCallback(obj.agent.signers)),
+		HostKeyCallback: obj.verifyHostKey,
+	}
+	return obj, nil
+}
+
+// SetAgentForwarding toggles ssh-agent forwarding for interactive sessions
+// started after the call.
+func SetAgentForwarding(s *Sshobject, enabled bool) error {
+	if s == nil {
+		return fmt.Errorf("nil ssh object")
+	}
+	if enabled && AgentSocket() == "" {
+		return fmt.Errorf("SSH_AUTH_SOCK is not set")
+	}
+	s.ForwardAgent = enabled
+	return nil
+}
+
+// requestAgentForwarding asks the server to forward the agent on sess. The
+// client-side handler for auth-agent channels is registered once per client,
+// shared or not.
+func (s *Sshobject) requestAgentForwarding(sess *ssh.Session) error {
+	fwd := &s.agentFwd
+	if sc := s.shared; sc != nil {
+		sc.mu.Lock()
+		defer sc.mu.Unlock()
+		fwd = &sc.agentFwd
+	}
+	if !*fwd {
+		if err := agent.ForwardToRemote(s.client, AgentSocket()); err != nil {
+			return fmt.Errorf("agent forwarding failed: %v", err)
+		}
+		*fwd = true
+	}
+	if err := agent.RequestAgentForwarding(sess); err != nil {
+		return fmt.Errorf("agent forwarding request failed: %v", err)
+	}
+	return nil
+}
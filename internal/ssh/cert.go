@@ -0,0 +1,83 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// CertInfo describes an OpenSSH user certificate attached to a session.
+type CertInfo struct {
+	KeyID       string    `json:"keyId"`
+	Serial      uint64    `json:"serial"`
+	Principals  []string  `json:"principals,omitempty"`
+	CA          string    `json:"ca"`
+	ValidAfter  time.Time `json:"validAfter"`
+	ValidBefore time.Time `json:"validBefore"` // zero means no expiry
+	Expired     bool      `json:"expired"`
+}
+
+// ParseCertificate parses an authorized_keys style user certificate such as
+// the contents of id_ed25519-cert.pub.
+func ParseCertificate(data []byte) (*ssh.Certificate, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(bytes.TrimSpace(data))
+	if err != nil {
+		return nil, fmt.Errorf("certificate parse failed: %v", err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("not an OpenSSH certificate: %s", pub.Type())
+	}
+	if cert.CertType != ssh.UserCert {
+		return nil, fmt.Errorf("not a user certificate")
+	}
+	return cert, nil
+}
+
+// SetCertificate attaches a user certificate to the private key s
+// authenticates with. The certificate is offered first and the bare key
+// stays as a fallback.
+func SetCertificate(s *Sshobject, data []byte) error {
+	if s == nil {
+		return fmt.Errorf("nil ssh object")
+	}
+	if s.signer == nil || s.config == nil {
+		return fmt.Errorf("certificate requires private key authentication")
+	}
+	cert, err := ParseCertificate(data)
+	if err != nil {
+		return err
+	}
+	certSigner, err := ssh.NewCertSigner(cert, s.signer)
+	if err != nil {
+		return fmt.Errorf("certificate does not match private key: %v", err)
+	}
+	s.config.Auth[0] = ssh.PublicKeys(certSigner, s.signer)
+	s.cert = cert
+	if info := certInfo(cert); info.Expired {
+		LogErrorf("Certificate %s for %s expired at %s", info.KeyID, s.Host, info.ValidBefore.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func certInfo(cert *ssh.Certificate) *CertInfo {
+	if cert == nil {
+		return nil
+	}
+	info := &CertInfo{
+		KeyID:      cert.KeyId,
+		Serial:     cert.Serial,
+		Principals: append([]string(nil), cert.ValidPrincipals...),
+		CA:         ssh.FingerprintSHA256(cert.SignatureKey),
+	}
+	if cert.ValidAfter != 0 {
+		info.ValidAfter = time.Unix(int64(cert.ValidAfter), 0).UTC()
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity {
+		info.ValidBefore = time.Unix(int64(cert.ValidBefore), 0).UTC()
+		info.Expired = time.Now().After(info.ValidBefore)
+	}
+	return info
+}
@@ -0,0 +1,185 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ChannelInfo describes one extra PTY channel of a connection.
+type ChannelInfo struct {
+	ID   string `json:"id"`
+	Rows int    `json:"rows"`
+	Cols int    `json:"cols"`
+	Open bool   `json:"open"`
+}
+
+// channelSet holds the extra interactive shells opened on one connection,
+// so split panes and duplicated tabs share a single authenticated client.
+type channelSet struct {
+	mu sync.Mutex
+	m  map[string]*ptyChannel
+}
+
+type ptyChannel struct {
+	st  *StreamSession
+	out io.Writer
+}
+
+// OpenChannel starts another interactive shell on the existing connection
+// of s, registered under id. The channel stays registered after its shell
+// ends until CloseChannel, so a dropped channel can be reopened.
+func OpenChannel(s *Sshobject, id string, out io.Writer, rows, cols int) (*StreamSession, error) {
+	if s == nil {
+		return nil, fmt.Errorf("nil ssh object")
+	}
+	if id == "" {
+		return nil, fmt.Errorf("channel id required")
+	}
+	s.chans.mu.Lock()
+	prev := s.chans.m[id]
+	if prev != nil {
+		select {
+		case <-prev.st.Done():
+		default:
+			s.chans.mu.Unlock()
+			return nil, fmt.Errorf("channel %s already open", id)
+		}
+	}
+	s.chans.mu.Unlock()
+
+	st, err := StartStream(s, out, rows, cols)
+	if err != nil {
+		return nil, err
+	}
+	s.chans.mu.Lock()
+	if s.chans.m[id] != prev {
+		// Another open of the same id won while the shell was starting.
+		s.chans.mu.Unlock()
+		_ = st.Close()
+		return nil, fmt.Errorf("channel %s already open", id)
+	}
+	if s.chans.m == nil {
+		s.chans.m = make(map[string]*ptyChannel)
+	}
+	s.chans.m[id] = &ptyChannel{st: st, out: out}
+	s.chans.mu.Unlock()
+	LogInfof("Channel %s opened on %s", id, s.Host)
+	return st, nil
+}
+
+// Channel returns the shell registered under id, or nil.
+func Channel(s *Sshobject, id string) *StreamSession {
+	if s == nil {
+		return nil
+	}
+	s.chans.mu.Lock()
+	defer s.chans.mu.Unlock()
+	if ch := s.chans.m[id]; ch != nil {
+		return ch.st
+	}
+	return nil
+}
+
+// Channels lists the registered channels sorted by ID.
+func Channels(s *Sshobject) []ChannelInfo {
+	if s == nil {
+		return nil
+	}
+	s.chans.mu.Lock()
+	defer s.chans.mu.Unlock()
+	out := make([]ChannelInfo, 0, len(s.chans.m))
+	for id, ch := range s.chans.m {
+		rows, cols := ch.st.Size()
+		info := ChannelInfo{ID: id, Rows: rows, Cols: cols, Open: true}
+		select {
+		case <-ch.st.Done():
+			info.Open = false
+		default:
+		}
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// CloseChannel ends the shell registered under id and forgets it.
+func CloseChannel(s *Sshobject, id string) error {
+	if s == nil {
+		return fmt.Errorf("nil ssh object")
+	}
+	s.chans.mu.Lock()
+	ch := s.chans.m[id]
+	delete(s.chans.m, id)
+	s.chans.mu.Unlock()
+	if ch == nil {
+		return fmt.Errorf("channel %s not found", id)
+	}
+	return ch.st.Close()
+}
+
+// ReopenChannels restarts every registered channel whose connection was
+// lost, keeping its size and output writer. Call it after Reconnect. It
+// returns the reopened channels by ID and the IDs that failed, which are
+// unregistered.
+func ReopenChannels(s *Sshobject) (reopened map[string]*StreamSession, failed []string) {
+	if s == nil {
+		return nil, nil
+	}
+	s.chans.mu.Lock()
+	var dropped []string
+	for id, ch := range s.chans.m {
+		select {
+		case <-ch.st.Done():
+			if ch.st.Dropped() {
+				dropped = append(dropped, id)
+			}
+		default:
+		}
+	}
+	s.chans.mu.Unlock()
+
+	reopened = make(map[string]*StreamSession, len(dropped))
+	for _, id := range dropped {
+		s.chans.mu.Lock()
+		ch := s.chans.m[id]
+		s.chans.mu.Unlock()
+		if ch == nil {
+			continue
+		}
+		rows, cols := ch.st.Size()
+		st, err := StartStream(s, ch.out, rows, cols)
+		s.chans.mu.Lock()
+		if s.chans.m[id] != ch {
+			// Closed while reopening.
+			s.chans.mu.Unlock()
+			if st != nil {
+				_ = st.Close()
+			}
+			continue
+		}
+		if err != nil {
+			delete(s.chans.m, id)
+			s.chans.mu.Unlock()
+			LogErrorf("Channel %s reopen on %s failed: %v", id, s.Host, err)
+			failed = append(failed, id)
+			continue
+		}
+		ch.st = st
+		s.chans.mu.Unlock()
+		reopened[id] = st
+	}
+	return reopened, failed
+}
+
+// closeChannels ends and forgets every channel of s.
+func (s *Sshobject) closeChannels() {
+	s.chans.mu.Lock()
+	chans := s.chans.m
+	s.chans.m = nil
+	s.chans.mu.Unlock()
+	for _, ch := range chans {
+		_ = ch.st.Close()
+	}
+}
@@ -0,0 +1,104 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ExecResult is the outcome of a non-interactive command. Stdout and Stderr
+// are empty when the output was streamed to writers instead.
+type ExecResult struct {
+	Stdout     string        `json:"stdout"`
+	Stderr     string        `json:"stderr"`
+	ExitStatus int           `json:"exitStatus"`
+	ExitSignal string        `json:"exitSignal,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	TimedOut   bool          `json:"timedOut,omitempty"`
+	Canceled   bool          `json:"canceled,omitempty"`
+}
+
+// Exec runs cmd on a fresh session of s without a PTY and collects its
+// output. timeout <= 0 means no limit beyond ctx. A command that exits
+// non-zero or by signal is not an error; see ExitStatus and ExitSignal.
+func Exec(ctx context.Context, s *Sshobject, cmd string, timeout time.Duration) (*ExecResult, error) {
+	var stdout, stderr bytes.Buffer
+	res, err := ExecStream(ctx, s, cmd, timeout, &stdout, &stderr)
+	if res != nil {
+		res.Stdout, res.Stderr = stdout.String(), stderr.String()
+	}
+	return res, err
+}
+
+// ExecStream is Exec with stdout and stderr copied to the given writers as
+// the command produces them.
+func ExecStream(ctx context.Context, s *Sshobject, cmd string, timeout time.Duration, stdout, stderr io.Writer) (*ExecResult, error) {
+	if s == nil || s.client == nil {
+		return nil, fmt.Errorf("ssh client not started")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	sess, err := s.client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Close()
+	sess.Stdout, sess.Stderr = stdout, stderr
+
+	start := time.Now()
+	if err := sess.Start(cmd); err != nil {
+		return nil, err
+	}
+	done := make(chan error, 1)
+	go func() { done <- sess.Wait() }()
+
+	res := &ExecResult{}
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// Ask the remote side to stop, then drop the channel so Wait returns
+		// even if the server ignores signals.
+		_ = sess.Signal(ssh.SIGKILL)
+		_ = sess.Close()
+		err = <-done
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			res.TimedOut = true
+		} else {
+			res.Canceled = true
+		}
+	}
+	res.Duration = time.Since(start)
+
+	var exitErr *ssh.ExitError
+	var missing *ssh.ExitMissingError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		res.ExitStatus = exitErr.ExitStatus()
+		res.ExitSignal = exitErr.Signal()
+	case errors.As(err, &missing):
+		res.ExitStatus = -1
+	default:
+		if !res.TimedOut && !res.Canceled {
+			return res, err
+		}
+	}
+	if res.TimedOut {
+		return res, fmt.Errorf("command timed out after %s", timeout)
+	}
+	if res.Canceled {
+		return res, ctx.Err()
+	}
+	return res, nil
+}
@@ -0,0 +1,133 @@
+package ssh
+
+import (
+	"net"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ForwardStats is a snapshot of one forward's traffic. BytesIn counts data
+// received from the clients of the listening side, BytesOut data sent back
+// to them.
+type ForwardStats struct {
+	BytesIn     int64              `json:"bytesIn"`
+	BytesOut    int64              `json:"bytesOut"`
+	Active      int                `json:"active"`
+	Total       int64              `json:"total"`
+	LastError   string             `json:"lastError,omitempty"`
+	LastErrorAt *time.Time         `json:"lastErrorAt,omitempty"`
+	Conns       []ForwardConnStats `json:"conns,omitempty"`
+}
+
+// ForwardConnStats describes one active client connection of a forward.
+type ForwardConnStats struct {
+	Peer       string    `json:"peer"`
+	Since      time.Time `json:"since"`
+	DurationMs int64     `json:"durationMs"`
+	BytesIn    int64     `json:"bytesIn"`
+	BytesOut   int64     `json:"bytesOut"`
+}
+
+// Forwarder is a running port forward.
+type Forwarder struct {
+	tracker *forwardTracker
+	stop    func() error
+}
+
+// Close stops the listener and every connection of the forward.
+func (f *Forwarder) Close() error { return f.stop() }
+
+// Addr returns the address the forward listens on.
+func (f *Forwarder) Addr() net.Addr { return f.tracker.listener.Addr() }
+
+// Stats returns a snapshot of the forward's traffic counters.
+func (f *Forwarder) Stats() ForwardStats { return f.tracker.stats() }
+
+// forwardConn counts the traffic of one accepted client connection.
+type forwardConn struct {
+	net.Conn
+	ft      *forwardTracker
+	peer    string
+	since   time.Time
+	in, out atomic.Int64
+}
+
+func (c *forwardConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.in.Add(int64(n))
+		c.ft.bytesIn.Add(int64(n))
+	}
+	return n, err
+}
+
+func (c *forwardConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	if n > 0 {
+		c.out.Add(int64(n))
+		c.ft.bytesOut.Add(int64(n))
+	}
+	return n, err
+}
+
+func (c *forwardConn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return c.Conn.Close()
+}
+
+// client wraps a connection accepted by the forward's listener so its
+// traffic is counted; track and untrack the returned value.
+func (ft *forwardTracker) client(c net.Conn) net.Conn {
+	fc := &forwardConn{Conn: c, ft: ft, since: time.Now()}
+	if ra := c.RemoteAddr(); ra != nil {
+		fc.peer = ra.String()
+	}
+	ft.total.Add(1)
+	return fc
+}
+
+// fail records err as the forward's last error.
+func (ft *forwardTracker) fail(err error) {
+	if ft == nil || err == nil {
+		return
+	}
+	ft.mu.Lock()
+	ft.lastErr = err.Error()
+	ft.lastErrAt = time.Now()
+	ft.mu.Unlock()
+}
+
+func (ft *forwardTracker) stats() ForwardStats {
+	now := time.Now()
+	ft.mu.Lock()
+	st := ForwardStats{
+		BytesIn:   ft.bytesIn.Load(),
+		BytesOut:  ft.bytesOut.Load(),
+		Total:     ft.total.Load(),
+		LastError: ft.lastErr,
+	}
+	if ft.lastErr != "" {
+		at := ft.lastErrAt
+		st.LastErrorAt = &at
+	}
+	for c := range ft.conns {
+		fc, ok := c.(*forwardConn)
+		if !ok {
+			continue
+		}
+		st.Conns = append(st.Conns, ForwardConnStats{
+			Peer:       fc.peer,
+			Since:      fc.since,
+			DurationMs: now.Sub(fc.since).Milliseconds(),
+			BytesIn:    fc.in.Load(),
+			BytesOut:   fc.out.Load(),
+		})
+	}
+	ft.mu.Unlock()
+	st.Active = len(st.Conns)
+	sort.Slice(st.Conns, func(i, j int) bool { return st.Conns[i].Since.Before(st.Conns[j].Since) })
+	return st
+}
@@ -0,0 +1,228 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// HostKeyInfo describes a server host key that is not yet trusted.
+type HostKeyInfo struct {
+	Host        string `json:"host"`
+	Remote      string `json:"remote"`
+	KeyType     string `json:"keyType"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// HostKeyPrompt is asked whether an unknown host key should be trusted
+// (trust on first use). Returning false rejects the connection.
+type HostKeyPrompt func(info HostKeyInfo) bool
+
+// HostKeyChangedError is returned when the server presents a key that differs
+// from the one recorded in known_hosts. It is never resolved by prompting.
+type HostKeyChangedError struct {
+	Host        string
+	Fingerprint string
+	Known       []knownhosts.KnownKey
+}
+
+func (e *HostKeyChangedError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "REMOTE HOST IDENTIFICATION HAS CHANGED for %s (got %s)", e.Host, e.Fingerprint)
+	for _, k := range e.Known {
+		fmt.Fprintf(&b, "; known %s at %s:%d", ssh.FingerprintSHA256(k.Key), k.Filename, k.Line)
+	}
+	return b.String()
+}
+
+// knownHostsMu serializes appends to the known_hosts file.
+var knownHostsMu sync.Mutex
+
+// DefaultKnownHostsPath returns the writable known_hosts file in the app config dir.
+func DefaultKnownHostsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil || dir == "" {
+		dir = "."
+	}
+	return filepath.Join(dir, "Erban", "known_hosts")
+}
+
+// userKnownHostsPath returns ~/.ssh/known_hosts, consulted read-only so hosts
+// already trusted by OpenSSH are not prompted for again.
+func userKnownHostsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
+
+// knownHostsPath returns the file new host keys are written to.
+func (s *Sshobject) knownHostsPath() string {
+	if p := strings.TrimSpace(s.KnownHosts); p != "" {
+		return p
+	}
+	return DefaultKnownHostsPath()
+}
+
+// knownHostsFiles lists the existing known_hosts files to verify against.
+func (s *Sshobject) knownHostsFiles() []string {
+	var files []string
+	seen := map[string]bool{}
+	for _, p := range []string{s.knownHostsPath(), userKnownHostsPath()} {
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		if st, err := os.Stat(p); err == nil && !st.IsDir() {
+			files = append(files, p)
+		}
+	}
+	return files
+}
+
+// verifyHostKey is the ssh.HostKeyCallback used by every Sshobject. Known keys
+// and host certificates signed by an @cert-authority pass, changed or revoked
+// keys fail hard, and unknown keys go through HostKeyPrompt and are appended
+// to known_hosts when accepted. A host certificate from an untrusted CA is
+// checked as its plain key, as ssh(1) does.
+func (s *Sshobject) verifyHostKey(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	s.hostCA = ""
+	if files := s.knownHostsFiles(); len(files) > 0 {
+		cb, err := knownhosts.New(files...)
+		if err != nil {
+			return fmt.Errorf("load known_hosts: %w", err)
+		}
+		if cert, ok := key.(*ssh.Certificate); ok {
+			err := cb(hostname, remote, cert)
+			if err == nil {
+				s.hostCA = ssh.FingerprintSHA256(cert.SignatureKey)
+				return nil
+			}
+			LogInfof("Host certificate for %s not trusted (%v); checking plain key", hostname, err)
+			key = cert.Key
+		}
+		err = cb(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+		var ke *knownhosts.KeyError
+		if !errors.As(err, &ke) {
+			LogErrorf("Host key check failed for %s: %v", hostname, err)
+			return err
+		}
+		if len(ke.Want) > 0 {
+			e := &HostKeyChangedError{Host: hostname, Fingerprint: ssh.FingerprintSHA256(key), Known: ke.Want}
+			LogErrorf("%v", e)
+			return e
+		}
+	} else if cert, ok := key.(*ssh.Certificate); ok {
+		key = cert.Key
+	}
+
+	info := HostKeyInfo{
+		Host:        hostname,
+		KeyType:     key.Type(),
+		Fingerprint: ssh.FingerprintSHA256(key),
+	}
+	if remote != nil {
+		info.Remote = remote.String()
+	}
+	if s.HostKeyPrompt == nil || !s.HostKeyPrompt(info) {
+		return fmt.Errorf("host key for %s (%s) not accepted", hostname, info.Fingerprint)
+	}
+	if err := appendKnownHost(s.knownHostsPath(), hostname, key); err != nil {
+		return err
+	}
+	LogInfof("Added host key for %s (%s) to known_hosts", hostname, info.Fingerprint)
+	return nil
+}
+
+// appendKnownHost writes an OpenSSH known_hosts line for hostname.
+func appendKnownHost(path, hostname string, key ssh.PublicKey) error {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		_ = os.MkdirAll(dir, 0o755)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write known_hosts: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(knownhosts.Line([]string{hostname}, key) + "\n"); err != nil {
+		return fmt.Errorf("failed to append known_hosts: %w", err)
+	}
+	return nil
+}
+
+// pinnedKeyAlgos maps a known_hosts key type to the host key algorithms
+// that produce it, certificate forms first.
+var pinnedKeyAlgos = map[string][]string{
+	ssh.KeyAlgoRSA: {
+		ssh.CertAlgoRSASHA512v01, ssh.CertAlgoRSASHA256v01, ssh.CertAlgoRSAv01,
+		ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA,
+	},
+	ssh.KeyAlgoECDSA256:   {ssh.CertAlgoECDSA256v01, ssh.KeyAlgoECDSA256},
+	ssh.KeyAlgoECDSA384:   {ssh.CertAlgoECDSA384v01, ssh.KeyAlgoECDSA384},
+	ssh.KeyAlgoECDSA521:   {ssh.CertAlgoECDSA521v01, ssh.KeyAlgoECDSA521},
+	ssh.KeyAlgoSKECDSA256: {ssh.CertAlgoSKECDSA256v01, ssh.KeyAlgoSKECDSA256},
+	ssh.KeyAlgoED25519:    {ssh.CertAlgoED25519v01, ssh.KeyAlgoED25519},
+	ssh.KeyAlgoSKED25519:  {ssh.CertAlgoSKED25519v01, ssh.KeyAlgoSKED25519},
+}
+
+// probeKey matches no known_hosts line; checking it lists the pinned keys.
+type probeKey struct{}
+
+func (probeKey) Type() string                        { return "probe" }
+func (probeKey) Marshal() []byte                     { return []byte("probe") }
+func (probeKey) Verify([]byte, *ssh.Signature) error { return errors.New("probe key") }
+
+// probeAddr stands in for the not yet dialed remote address of a probe;
+// knownhosts only reads its host:port.
+type probeAddr string
+
+func (a probeAddr) Network() string { return "tcp" }
+func (a probeAddr) String() string  { return string(a) }
+
+// hostKeyAlgorithms returns the host key algorithms for the key types
+// known_hosts pins for addr, so the server presents a key we can verify
+// instead of one of another type, as OpenSSH does. It returns nil when no
+// key is pinned.
+func (s *Sshobject) hostKeyAlgorithms(addr string) []string {
+	files := s.knownHostsFiles()
+	if len(files) == 0 {
+		return nil
+	}
+	cb, err := knownhosts.New(files...)
+	if err != nil {
+		return nil
+	}
+	var ke *knownhosts.KeyError
+	if err := cb(addr, probeAddr(addr), probeKey{}); !errors.As(err, &ke) {
+		return nil
+	}
+	var algos []string
+	seen := map[string]bool{}
+	for _, k := range ke.Want {
+		list, ok := pinnedKeyAlgos[k.Key.Type()]
+		if !ok {
+			list = []string{k.Key.Type()}
+		}
+		for _, a := range list {
+			if !seen[a] {
+				seen[a] = true
+				algos = append(algos, a)
+			}
+		}
+	}
+	return algos
+}
@@ -0,0 +1,231 @@
+package ssh
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const httpProxyHandshakeTimeout = 30 * time.Second
+
+// hopHeaders are removed when relaying a plain proxy request (RFC 7230 6.1).
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// HTTPProxyForward starts a proxy on localAddr that tunnels via SSH. It
+// speaks HTTP/1.1 (CONNECT and absolute-URI requests) and, when the first
+// byte is a SOCKS5 version byte, SOCKS5 as DynamicForward does. opts
+// applies to both: a Username requires Basic Proxy-Authorization for HTTP.
+func (s *Sshobject) HTTPProxyForward(localAddr string, opts SocksOptions) (*Forwarder, error) {
+	return s.serveDynamic(localAddr, "HTTP proxy", &GlobalPortForward.proxies, func(c net.Conn, tracker *forwardTracker) {
+		s.handleMixedProxy(c, tracker, opts)
+	})
+}
+
+// handleMixedProxy picks SOCKS5 or HTTP by peeking the first byte.
+func (s *Sshobject) handleMixedProxy(c net.Conn, tracker *forwardTracker, opts SocksOptions) {
+	defer tracker.untrackConn(c)
+	defer c.Close()
+	br := bufio.NewReader(c)
+	_ = c.SetReadDeadline(time.Now().Add(httpProxyHandshakeTimeout))
+	first, err := br.Peek(1)
+	if err != nil {
+		return
+	}
+	if first[0] == socksVersion {
+		_, _ = br.Discard(1)
+		s.serveSocks5(c, br, tracker, opts)
+		return
+	}
+	s.serveHTTPProxy(c, br, tracker, opts)
+}
+
+// serveHTTPProxy handles requests from one HTTP proxy client until the
+// connection closes or is handed over to a CONNECT tunnel.
+func (s *Sshobject) serveHTTPProxy(c net.Conn, br *bufio.Reader, tracker *forwardTracker, opts SocksOptions) {
+	// plain requests reuse one upstream connection per target host
+	var (
+		upstream   net.Conn
+		upstreamBr *bufio.Reader
+		upHost     string
+	)
+	defer func() {
+		if upstream != nil {
+			tracker.untrackConn(upstream)
+			upstream.Close()
+		}
+	}()
+
+	for {
+		_ = c.SetReadDeadline(time.Now().Add(httpProxyHandshakeTimeout))
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			if err != io.EOF {
+				LogErrorf("HTTP proxy %s: %v", c.RemoteAddr(), err)
+			}
+			return
+		}
+		_ = c.SetReadDeadline(time.Time{})
+
+		if !httpProxyAuthorized(req, opts) {
+			writeHTTPProxyError(c, http.StatusProxyAuthRequired, `Proxy-Authenticate: Basic realm="ssh"`)
+			_, _ = io.Copy(io.Discard, req.Body)
+			req.Body.Close()
+			if req.Close {
+				return
+			}
+			continue
+		}
+
+		if req.Method == http.MethodConnect {
+			s.httpConnect(c, br, tracker, req.Host)
+			return
+		}
+
+		if req.URL.Scheme != "http" || req.URL.Host == "" {
+			writeHTTPProxyError(c, http.StatusBadRequest, "")
+			return
+		}
+		target := req.URL.Host
+		if req.URL.Port() == "" {
+			target = net.JoinHostPort(req.URL.Hostname(), "80")
+		}
+		if upstream == nil || upHost != target {
+			if upstream != nil {
+				tracker.untrackConn(upstream)
+				upstream.Close()
+				upstream = nil
+			}
+			rc, err := s.client.Dial("tcp", target)
+			if err != nil {
+				LogErrorf("HTTP proxy dial %s via SSH failed: %v", target, err)
+				tracker.fail(err)
+				writeHTTPProxyError(c, http.StatusBadGateway, "")
+				return
+			}
+			if !tracker.trackConn(rc) {
+				rc.Close()
+				return
+			}
+			upstream, upstreamBr, upHost = rc, bufio.NewReader(rc), target
+		}
+
+		upgrade := req.Header.Get("Upgrade")
+		removeHopHeaders(req.Header)
+		if upgrade != "" {
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", upgrade)
+		}
+		req.RequestURI = ""
+		if err := req.Write(upstream); err != nil {
+			LogErrorf("HTTP proxy write to %s failed: %v", target, err)
+			tracker.fail(err)
+			writeHTTPProxyError(c, http.StatusBadGateway, "")
+			return
+		}
+		resp, err := http.ReadResponse(upstreamBr, req)
+		if err != nil {
+			LogErrorf("HTTP proxy read from %s failed: %v", target, err)
+			tracker.fail(err)
+			writeHTTPProxyError(c, http.StatusBadGateway, "")
+			return
+		}
+		if resp.StatusCode == http.StatusSwitchingProtocols {
+			if err := resp.Write(c); err != nil {
+				return
+			}
+			proxyPipe(bufferedConn{c, br}, bufferedConn{upstream, upstreamBr})
+			return
+		}
+		keepAlive := !req.Close && !resp.Close
+		removeHopHeaders(resp.Header)
+		err = resp.Write(c)
+		resp.Body.Close()
+		if err != nil || !keepAlive {
+			return
+		}
+	}
+}
+
+// httpConnect opens a tunnel to target for a CONNECT request.
+func (s *Sshobject) httpConnect(c net.Conn, br *bufio.Reader, tracker *forwardTracker, target string) {
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		writeHTTPProxyError(c, http.StatusBadRequest, "")
+		return
+	}
+	rc, err := s.client.Dial("tcp", target)
+	if err != nil {
+		LogErrorf("HTTP CONNECT %s via SSH failed: %v", target, err)
+		tracker.fail(err)
+		writeHTTPProxyError(c, http.StatusBadGateway, "")
+		return
+	}
+	if !tracker.trackConn(rc) {
+		rc.Close()
+		return
+	}
+	defer func() {
+		tracker.untrackConn(rc)
+		rc.Close()
+	}()
+	if _, err := io.WriteString(c, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		return
+	}
+	proxyPipe(bufferedConn{c, br}, rc)
+}
+
+// httpProxyAuthorized checks Basic Proxy-Authorization against opts.
+func httpProxyAuthorized(req *http.Request, opts SocksOptions) bool {
+	if opts.Username == "" {
+		return true
+	}
+	scheme, cred, ok := strings.Cut(req.Header.Get("Proxy-Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return false
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(cred))
+	if err != nil {
+		return false
+	}
+	user, pass, _ := strings.Cut(string(raw), ":")
+	okUser := subtle.ConstantTimeCompare([]byte(user), []byte(opts.Username)) == 1
+	okPass := subtle.ConstantTimeCompare([]byte(pass), []byte(opts.Password)) == 1
+	return okUser && okPass
+}
+
+func removeHopHeaders(h http.Header) {
+	for _, f := range h["Connection"] {
+		for _, name := range strings.Split(f, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+func writeHTTPProxyError(w io.Writer, code int, header string) {
+	if header != "" {
+		header += "\r\n"
+	}
+	text := http.StatusText(code)
+	_, _ = fmt.Fprintf(w, "HTTP/1.1 %d %s\r\n%sContent-Type: text/plain\r\nContent-Length: %d\r\n\r\n%s",
+		code, text, header, len(text), text)
+}
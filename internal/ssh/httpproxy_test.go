@@ -0,0 +1,302 @@
+package ssh
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testSSHClient returns a client of an in-process SSH server that serves
+// direct-tcpip channels by dialing the requested address.
+func testSSHClient(t *testing.T) *ssh.Client {
+	t.Helper()
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	hostKey, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ssh.ServerConfig{NoClientAuth: true}
+	cfg.AddHostKey(hostKey)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSSH(c, cfg)
+		}
+	}()
+
+	client, err := ssh.Dial("tcp", ln.Addr().String(), &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func serveTestSSH(c net.Conn, cfg *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(c, cfg)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		if nc.ChannelType() != "direct-tcpip" {
+			_ = nc.Reject(ssh.UnknownChannelType, nc.ChannelType())
+			continue
+		}
+		var msg struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if err := ssh.Unmarshal(nc.ExtraData(), &msg); err != nil {
+			_ = nc.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		target, err := net.Dial("tcp", net.JoinHostPort(msg.Host, strconv.Itoa(int(msg.Port))))
+		if err != nil {
+			_ = nc.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		ch, chReqs, err := nc.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+		go ssh.DiscardRequests(chReqs)
+		go func() {
+			defer ch.Close()
+			defer target.Close()
+			go func() { _, _ = io.Copy(target, ch); closeWrite(target) }()
+			_, _ = io.Copy(ch, target)
+		}()
+	}
+}
+
+// echoServer accepts connections and writes back whatever it reads.
+func echoServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				_, _ = io.Copy(c, c)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// mixedProxyPipe runs handleMixedProxy on the server end of a pipe.
+func mixedProxyPipe(t *testing.T, s *Sshobject, opts SocksOptions) *bufio.ReadWriter {
+	t.Helper()
+	client, server := net.Pipe()
+	tracker := newForwardTracker(nil)
+	tracker.trackConn(server)
+	go s.handleMixedProxy(server, tracker, opts)
+	t.Cleanup(func() { client.Close() })
+	_ = client.SetDeadline(time.Now().Add(5 * time.Second))
+	return bufio.NewReadWriter(bufio.NewReader(client), bufio.NewWriter(client))
+}
+
+func basicAuth(user, pass string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+}
+
+func TestHTTPProxyAuthorized(t *testing.T) {
+	opts := SocksOptions{Username: "alice", Password: "s3cret"}
+	tests := []struct {
+		name   string
+		opts   SocksOptions
+		header string
+		want   bool
+	}{
+		{"no auth configured", SocksOptions{}, "", true},
+		{"valid", opts, basicAuth("alice", "s3cret"), true},
+		{"scheme case", opts, "basic " + base64.StdEncoding.EncodeToString([]byte("alice:s3cret")), true},
+		{"wrong password", opts, basicAuth("alice", "nope"), false},
+		{"wrong user", opts, basicAuth("bob", "s3cret"), false},
+		{"missing", opts, "", false},
+		{"other scheme", opts, "Bearer abc", false},
+		{"bad base64", opts, "Basic !!!", false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodConnect, "http://example.com:443", nil)
+		if tt.header != "" {
+			req.Header.Set("Proxy-Authorization", tt.header)
+		}
+		if got := httpProxyAuthorized(req, tt.opts); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveHopHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Connection", "X-Trace, keep-alive")
+	h.Set("X-Trace", "1")
+	h.Set("Keep-Alive", "timeout=5")
+	h.Set("Proxy-Connection", "keep-alive")
+	h.Set("Proxy-Authorization", basicAuth("a", "b"))
+	h.Set("Te", "trailers")
+	h.Set("Transfer-Encoding", "chunked")
+	h.Set("Upgrade", "websocket")
+	h.Set("Accept", "*/*")
+	h.Set("User-Agent", "test")
+
+	removeHopHeaders(h)
+	want := http.Header{"Accept": {"*/*"}, "User-Agent": {"test"}}
+	if !reflect.DeepEqual(h, want) {
+		t.Errorf("headers = %v, want %v", h, want)
+	}
+}
+
+func TestHTTPProxyConnectAuthRetry(t *testing.T) {
+	s := &Sshobject{client: testSSHClient(t)}
+	target := echoServer(t)
+	rw := mixedProxyPipe(t, s, SocksOptions{Username: "alice", Password: "s3cret"})
+
+	_, _ = rw.WriteString("CONNECT " + target + " HTTP/1.1\r\nHost: " + target + "\r\n\r\n")
+	_ = rw.Flush()
+	resp, err := http.ReadResponse(rw.Reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusProxyAuthRequired {
+		t.Fatalf("status = %d, want 407", resp.StatusCode)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Proxy-Authenticate"), "Basic") {
+		t.Errorf("Proxy-Authenticate = %q", resp.Header.Get("Proxy-Authenticate"))
+	}
+
+	// Retry on the same connection with credentials.
+	_, _ = rw.WriteString("CONNECT " + target + " HTTP/1.1\r\nHost: " + target +
+		"\r\nProxy-Authorization: " + basicAuth("alice", "s3cret") + "\r\n\r\n")
+	_ = rw.Flush()
+	resp, err = http.ReadResponse(rw.Reader, &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	_, _ = rw.WriteString("ping")
+	_ = rw.Flush()
+	got := make([]byte, 4)
+	if _, err := io.ReadFull(rw, got); err != nil || string(got) != "ping" {
+		t.Fatalf("tunnel echo = %q, %v", got, err)
+	}
+}
+
+func TestHTTPProxyUpgrade(t *testing.T) {
+	s := &Sshobject{client: testSSHClient(t)}
+
+	// The upstream switches protocols, then echoes.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	gotHeaders := make(chan http.Header, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		br := bufio.NewReader(c)
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			return
+		}
+		gotHeaders <- req.Header
+		_, _ = io.WriteString(c, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		_, _ = io.Copy(c, br)
+	}()
+
+	rw := mixedProxyPipe(t, s, SocksOptions{})
+	addr := ln.Addr().String()
+	_, _ = rw.WriteString("GET http://" + addr + "/ws HTTP/1.1\r\nHost: " + addr +
+		"\r\nConnection: Upgrade\r\nUpgrade: echo\r\nProxy-Connection: keep-alive\r\n\r\n")
+	_ = rw.Flush()
+	resp, err := http.ReadResponse(rw.Reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", resp.StatusCode)
+	}
+	h := <-gotHeaders
+	if h.Get("Upgrade") != "echo" || h.Get("Connection") != "Upgrade" || h.Get("Proxy-Connection") != "" {
+		t.Errorf("upstream headers = %v", h)
+	}
+
+	_, _ = rw.WriteString("hello")
+	_ = rw.Flush()
+	got := make([]byte, 5)
+	if _, err := io.ReadFull(rw, got); err != nil || string(got) != "hello" {
+		t.Fatalf("upgraded echo = %q, %v", got, err)
+	}
+}
+
+func TestHandleMixedProxyDetect(t *testing.T) {
+	s := &Sshobject{}
+
+	// A SOCKS5 version byte is served as SOCKS5.
+	rw := mixedProxyPipe(t, s, SocksOptions{})
+	_, _ = rw.Write([]byte{5, 1, socksMethodNone})
+	_ = rw.Flush()
+	got := make([]byte, 2)
+	if _, err := io.ReadFull(rw, got); err != nil || got[0] != 5 || got[1] != socksMethodNone {
+		t.Fatalf("socks greeting reply = %x, %v", got, err)
+	}
+
+	// Anything else is HTTP; a CONNECT target without a port is rejected
+	// before dialing.
+	rw = mixedProxyPipe(t, s, SocksOptions{})
+	_, _ = rw.WriteString("CONNECT example.com HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	_ = rw.Flush()
+	resp, err := http.ReadResponse(rw.Reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", resp.StatusCode)
+	}
+}
@@ -0,0 +1,65 @@
+package ssh
+
+import "net/url"
+
+// Auth methods recorded on an Sshobject.
+const (
+	AuthPassword  = "password"
+	AuthPublicKey = "publickey"
+	AuthAgent     = "agent"
+)
+
+// SessionInfo summarizes an Sshobject for display.
+type SessionInfo struct {
+	Host         string    `json:"host"`
+	User         string    `json:"user"`
+	Label        string    `json:"label,omitempty"`
+	Auth         string    `json:"auth"`
+	Connected    bool      `json:"connected"`
+	Proxy        string    `json:"proxy,omitempty"`
+	Jumps        []string  `json:"jumps,omitempty"`
+	ForwardAgent bool      `json:"forwardAgent"`
+	ForwardX11   bool      `json:"forwardX11"`
+	Cert         *CertInfo `json:"cert,omitempty"`
+	// HostCertAuthority is the fingerprint of the CA that signed the
+	// server's host certificate, if one was verified.
+	HostCertAuthority string `json:"hostCertAuthority,omitempty"`
+	// LatencyMs is the last keepalive round-trip time.
+	LatencyMs float64 `json:"latencyMs,omitempty"`
+	// SharedRefs is the number of sessions on a pooled connection; 0 when
+	// the connection is private.
+	SharedRefs int `json:"sharedRefs,omitempty"`
+}
+
+// Info returns a snapshot of s for the UI.
+func Info(s *Sshobject) SessionInfo {
+	if s == nil {
+		return SessionInfo{}
+	}
+	info := SessionInfo{
+		Host:         s.Host,
+		User:         s.User,
+		Label:        s.Label,
+		Auth:         s.auth,
+		Connected:    s.client != nil,
+		ForwardAgent: s.ForwardAgent,
+		ForwardX11:   s.ForwardX11,
+		Cert:         certInfo(s.cert),
+	}
+	if s.client != nil {
+		info.HostCertAuthority = s.hostCA
+		info.LatencyMs = float64(s.Latency().Microseconds()) / 1000
+	}
+	if s.shared != nil {
+		info.SharedRefs = sharedClients.refCount(s.shared)
+	}
+	if u, err := url.Parse(s.P.URL); err == nil && s.P.URL != "" {
+		info.Proxy = u.Redacted()
+	}
+	for _, hop := range s.Jumps {
+		if hop != nil {
+			info.Jumps = append(info.Jumps, hop.User+"@"+hop.Host)
+		}
+	}
+	return info
+}
@@ -0,0 +1,94 @@
+package ssh
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// maxKeyboardInteractiveTries bounds how often a rejected challenge (for
+// example a mistyped one-time code) is retried.
+const maxKeyboardInteractiveTries = 3
+
+// KeyboardQuestion is one prompt of a keyboard-interactive challenge.
+type KeyboardQuestion struct {
+	Prompt string `json:"prompt"`
+	Echo   bool   `json:"echo"`
+}
+
+// KeyboardChallenge is a keyboard-interactive round sent by the server,
+// such as a password followed by a TOTP code.
+type KeyboardChallenge struct {
+	Host        string             `json:"host"`
+	User        string             `json:"user"`
+	Name        string             `json:"name,omitempty"`
+	Instruction string             `json:"instruction,omitempty"`
+	Questions   []KeyboardQuestion `json:"questions"`
+}
+
+// KeyboardInteractivePrompt answers a keyboard-interactive challenge with
+// one answer per question. Returning false aborts authentication.
+type KeyboardInteractivePrompt func(ch KeyboardChallenge) ([]string, bool)
+
+// SetPassword sets the password answered to password and keyboard-interactive
+// password prompts, so servers that require publickey followed by a password
+// can be satisfied without prompting.
+func SetPassword(s *Sshobject, passwd string) error {
+	if s == nil {
+		return fmt.Errorf("nil ssh object")
+	}
+	if s.config == nil {
+		return fmt.Errorf("SSH config not initialized for %s", s.Host)
+	}
+	s.Passwd = passwd
+	if s.hasPasswdAuth {
+		return nil
+	}
+	// Insert before keyboard-interactive so the cheaper method is tried first.
+	n := len(s.config.Auth)
+	s.config.Auth = append(s.config.Auth[:n-1:n-1], s.passwordAuth(), s.config.Auth[n-1])
+	return nil
+}
+
+// passwordAuth returns a password method that reads Passwd at auth time.
+func (s *Sshobject) passwordAuth() ssh.AuthMethod {
+	s.hasPasswdAuth = true
+	return ssh.PasswordCallback(func() (string, error) { return s.Passwd, nil })
+}
+
+// authMethods returns primary followed by keyboard-interactive, which the
+// client falls back to or chains after a partial success as the server asks.
+func (s *Sshobject) authMethods(primary ...ssh.AuthMethod) []ssh.AuthMethod {
+	kbd := ssh.RetryableAuthMethod(ssh.KeyboardInteractive(s.keyboardInteractive), maxKeyboardInteractiveTries)
+	return append(primary, kbd)
+}
+
+// keyboardInteractive relays a challenge to KeyboardPrompt. A lone hidden
+// password question is answered from Passwd on the first round.
+func (s *Sshobject) keyboardInteractive(name, instruction string, questions []string, echos []bool) ([]string, error) {
+	if len(questions) == 0 {
+		// Servers may send an empty round carrying only instructions.
+		return []string{}, nil
+	}
+	if s.Passwd != "" && !s.kbdPasswdUsed && len(questions) == 1 && !echos[0] &&
+		strings.Contains(strings.ToLower(questions[0]), "password") {
+		s.kbdPasswdUsed = true
+		return []string{s.Passwd}, nil
+	}
+	if s.KeyboardPrompt == nil {
+		return nil, fmt.Errorf("keyboard-interactive authentication for %s needs a prompt", s.Host)
+	}
+	ch := KeyboardChallenge{Host: s.Host, User: s.User, Name: name, Instruction: instruction}
+	for i, q := range questions {
+		ch.Questions = append(ch.Questions, KeyboardQuestion{Prompt: q, Echo: i < len(echos) && echos[i]})
+	}
+	answers, ok := s.KeyboardPrompt(ch)
+	if !ok {
+		return nil, fmt.Errorf("keyboard-interactive authentication cancelled")
+	}
+	if len(answers) != len(questions) {
+		return nil, fmt.Errorf("keyboard-interactive: got %d answers for %d questions", len(answers), len(questions))
+	}
+	return answers, nil
+}
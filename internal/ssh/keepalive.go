@@ -0,0 +1,108 @@
+package ssh
+
+import (
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Default keepalive settings used for zero-valued Keepalive fields.
+const (
+	DefaultKeepaliveInterval = 15 * time.Second
+	DefaultKeepaliveMissed   = 3
+)
+
+// Keepalive configures keepalive@openssh.com probes on the connection, like
+// ssh(1)'s ServerAliveInterval/ServerAliveCountMax. Zero fields take the
+// Default* values.
+type Keepalive struct {
+	Disabled  bool          `json:"disabled"`
+	Interval  time.Duration `json:"interval"`
+	MaxMissed int           `json:"maxMissed"`
+}
+
+func (k Keepalive) withDefaults() Keepalive {
+	if k.Interval <= 0 {
+		k.Interval = DefaultKeepaliveInterval
+	}
+	if k.MaxMissed <= 0 {
+		k.MaxMissed = DefaultKeepaliveMissed
+	}
+	return k
+}
+
+// Latency returns the last measured keepalive round-trip time, or 0 when
+// none has been measured on the current connection.
+func (s *Sshobject) Latency() time.Duration {
+	if s == nil {
+		return 0
+	}
+	if sc := s.shared; sc != nil {
+		return time.Duration(sc.latency.Load())
+	}
+	return time.Duration(s.latency.Load())
+}
+
+// startKeepalive probes the private connection c until it closes.
+func (s *Sshobject) startKeepalive(c *ssh.Client) {
+	s.latency.Store(0)
+	keepalive(c, s.KA, s.Host, func(rtt time.Duration) {
+		s.latency.Store(int64(rtt))
+		if cb := s.OnLatency; cb != nil {
+			cb(rtt)
+		}
+	})
+}
+
+// keepalive probes c until it closes, passing each round-trip time to
+// report. When MaxMissed probes in a row go unanswered the connection is
+// declared dead and closed, which ends every session on it.
+func keepalive(c *ssh.Client, ka Keepalive, host string, report func(time.Duration)) {
+	if ka.Disabled {
+		return
+	}
+	ka = ka.withDefaults()
+	closed := make(chan struct{})
+	go func() {
+		_ = c.Wait()
+		close(closed)
+	}()
+	go func() {
+		t := time.NewTicker(ka.Interval)
+		defer t.Stop()
+		missed := 0
+		for {
+			select {
+			case <-closed:
+				return
+			case <-t.C:
+			}
+			start := time.Now()
+			reply := make(chan error, 1)
+			go func() {
+				// Servers answer unknown global requests with a failure,
+				// which still proves the link is alive.
+				_, _, err := c.SendRequest("keepalive@openssh.com", true, nil)
+				reply <- err
+			}()
+			select {
+			case <-closed:
+				return
+			case err := <-reply:
+				if err != nil {
+					return
+				}
+				missed = 0
+				report(time.Since(start))
+			case <-time.After(ka.Interval):
+				missed++
+				LogErrorf("Keepalive to %s unanswered (%d/%d)", host, missed, ka.MaxMissed)
+				if missed >= ka.MaxMissed {
+					LogErrorf("Connection to %s declared dead", host)
+					_ = c.Close()
+					return
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,389 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/dsa"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/ssh"
+)
+
+// maxPassphraseAttempts bounds how often a wrong passphrase is re-asked.
+const maxPassphraseAttempts = 3
+
+// PassphraseInfo describes an encrypted private key that needs a passphrase.
+type PassphraseInfo struct {
+	Name        string `json:"name"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Retry       bool   `json:"retry"`
+}
+
+// PassphrasePrompt is asked for the passphrase of an encrypted private key.
+// Returning false cancels parsing.
+type PassphrasePrompt func(info PassphraseInfo) (string, bool)
+
+// ParsePrivateKey parses an unencrypted or encrypted PEM, OpenSSH or PuTTY
+// (.ppk v2/v3) private key. Encrypted keys ask for their passphrase through
+// ask; name identifies the key in the prompt.
+func ParsePrivateKey(data []byte, name string, ask PassphrasePrompt) (ssh.Signer, error) {
+	if isPPK(data) {
+		return parsePPKWithPrompt(data, name, ask)
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	if err == nil {
+		return signer, nil
+	}
+	var missing *ssh.PassphraseMissingError
+	if !errors.As(err, &missing) {
+		return nil, fmt.Errorf("private key parse failed: %w", err)
+	}
+	info := PassphraseInfo{Name: name}
+	if missing.PublicKey != nil {
+		info.Fingerprint = ssh.FingerprintSHA256(missing.PublicKey)
+	}
+	return askPassphrase(info, ask, func(pass []byte) (ssh.Signer, error) {
+		return ssh.ParsePrivateKeyWithPassphrase(data, pass)
+	})
+}
+
+// askPassphrase prompts until parse accepts the passphrase, the user
+// cancels, or maxPassphraseAttempts is reached.
+func askPassphrase(info PassphraseInfo, ask PassphrasePrompt, parse func([]byte) (ssh.Signer, error)) (ssh.Signer, error) {
+	if ask == nil {
+		return nil, fmt.Errorf("private key %s is encrypted and no passphrase was provided", info.Name)
+	}
+	var lastErr error
+	for i := 0; i < maxPassphraseAttempts; i++ {
+		info.Retry = i > 0
+		pass, ok := ask(info)
+		if !ok {
+			return nil, fmt.Errorf("passphrase for %s not provided", info.Name)
+		}
+		signer, err := parse([]byte(pass))
+		if err == nil {
+			return signer, nil
+		}
+		if !errors.Is(err, x509.IncorrectPasswordError) {
+			return nil, fmt.Errorf("private key parse failed: %w", err)
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("private key %s: %w", info.Name, lastErr)
+}
+
+// ---- PuTTY private key files ----
+
+// ppkFile is a decoded PuTTY-User-Key-File.
+type ppkFile struct {
+	version    int
+	algo       string
+	encryption string
+	comment    string
+	public     []byte
+	private    []byte
+	mac        []byte
+	headers    map[string]string
+}
+
+func isPPK(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("PuTTY-User-Key-File-"))
+}
+
+func parsePPKWithPrompt(data []byte, name string, ask PassphrasePrompt) (ssh.Signer, error) {
+	f, err := decodePPK(data)
+	if err != nil {
+		return nil, err
+	}
+	if f.encryption == "none" {
+		return f.signer(nil)
+	}
+	info := PassphraseInfo{Name: name}
+	if pub, err := ssh.ParsePublicKey(f.public); err == nil {
+		info.Fingerprint = ssh.FingerprintSHA256(pub)
+	}
+	if info.Name == "" {
+		info.Name = f.comment
+	}
+	return askPassphrase(info, ask, f.signer)
+}
+
+// decodePPK splits a .ppk file into its headers and base64 blobs.
+func decodePPK(data []byte) (*ppkFile, error) {
+	f := &ppkFile{headers: map[string]string{}}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	readLines := func(n int) ([]byte, error) {
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			if !sc.Scan() {
+				return nil, fmt.Errorf("ppk: truncated key data")
+			}
+			b.WriteString(strings.TrimSpace(sc.Text()))
+		}
+		return base64.StdEncoding.DecodeString(b.String())
+	}
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("ppk: malformed line %q", line)
+		}
+		switch {
+		case strings.HasPrefix(key, "PuTTY-User-Key-File-"):
+			v, err := strconv.Atoi(strings.TrimPrefix(key, "PuTTY-User-Key-File-"))
+			if err != nil || (v != 2 && v != 3) {
+				return nil, fmt.Errorf("ppk: unsupported version %q", key)
+			}
+			f.version, f.algo = v, val
+		case key == "Public-Lines" || key == "Private-Lines":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return nil, fmt.Errorf("ppk: bad %s", key)
+			}
+			blob, err := readLines(n)
+			if err != nil {
+				return nil, err
+			}
+			if key == "Public-Lines" {
+				f.public = blob
+			} else {
+				f.private = blob
+			}
+		case key == "Private-MAC":
+			mac, err := hex.DecodeString(val)
+			if err != nil {
+				return nil, fmt.Errorf("ppk: bad Private-MAC")
+			}
+			f.mac = mac
+		default:
+			f.headers[key] = val
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	f.encryption = f.headers["Encryption"]
+	f.comment = f.headers["Comment"]
+	if f.version == 0 || f.public == nil || f.private == nil || f.mac == nil {
+		return nil, fmt.Errorf("ppk: missing required fields")
+	}
+	if f.encryption != "none" && f.encryption != "aes256-cbc" {
+		return nil, fmt.Errorf("ppk: unsupported encryption %s", f.encryption)
+	}
+	return f, nil
+}
+
+// keys derives the cipher key, IV and MAC key for passphrase.
+func (f *ppkFile) keys(passphrase []byte) (cipherKey, iv, macKey []byte, err error) {
+	if f.version == 2 {
+		if f.encryption != "none" {
+			h0 := sha1.Sum(append([]byte{0, 0, 0, 0}, passphrase...))
+			h1 := sha1.Sum(append([]byte{0, 0, 0, 1}, passphrase...))
+			cipherKey = append(h0[:], h1[:]...)[:32]
+			iv = make([]byte, aes.BlockSize)
+		}
+		mk := sha1.Sum(append([]byte("putty-private-key-file-mac-key"), passphrase...))
+		return cipherKey, iv, mk[:], nil
+	}
+	if f.encryption == "none" {
+		return nil, nil, nil, nil
+	}
+	salt, err := hex.DecodeString(f.headers["Argon2-Salt"])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("ppk: bad Argon2-Salt")
+	}
+	mem, err1 := strconv.ParseUint(f.headers["Argon2-Memory"], 10, 32)
+	passes, err2 := strconv.ParseUint(f.headers["Argon2-Passes"], 10, 32)
+	par, err3 := strconv.ParseUint(f.headers["Argon2-Parallelism"], 10, 8)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, nil, nil, fmt.Errorf("ppk: bad Argon2 parameters")
+	}
+	var out []byte
+	switch f.headers["Key-Derivation"] {
+	case "Argon2id":
+		out = argon2.IDKey(passphrase, salt, uint32(passes), uint32(mem), uint8(par), 80)
+	case "Argon2i":
+		out = argon2.Key(passphrase, salt, uint32(passes), uint32(mem), uint8(par), 80)
+	default:
+		return nil, nil, nil, fmt.Errorf("ppk: unsupported key derivation %s", f.headers["Key-Derivation"])
+	}
+	return out[:32], out[32:48], out[48:], nil
+}
+
+// signer decrypts and verifies the private blob and builds a signer.
+func (f *ppkFile) signer(passphrase []byte) (ssh.Signer, error) {
+	cipherKey, iv, macKey, err := f.keys(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	priv := f.private
+	if f.encryption != "none" {
+		if len(priv)%aes.BlockSize != 0 {
+			return nil, fmt.Errorf("ppk: private blob is not block aligned")
+		}
+		block, err := aes.NewCipher(cipherKey)
+		if err != nil {
+			return nil, err
+		}
+		priv = make([]byte, len(f.private))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(priv, f.private)
+	}
+
+	var mac hash.Hash
+	if f.version == 2 {
+		mac = hmac.New(sha1.New, macKey)
+	} else {
+		mac = hmac.New(sha256.New, macKey)
+	}
+	for _, field := range [][]byte{[]byte(f.algo), []byte(f.encryption), []byte(f.comment), f.public, priv} {
+		_ = binary.Write(mac, binary.BigEndian, uint32(len(field)))
+		mac.Write(field)
+	}
+	if !hmac.Equal(mac.Sum(nil), f.mac) {
+		if f.encryption != "none" {
+			return nil, x509.IncorrectPasswordError
+		}
+		return nil, fmt.Errorf("ppk: MAC verification failed")
+	}
+
+	key, err := ppkPrivateKey(f.algo, f.public, priv)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewSignerFromKey(key)
+}
+
+// ppkPrivateKey rebuilds a crypto private key from PuTTY's public and
+// private blobs.
+func ppkPrivateKey(algo string, public, private []byte) (any, error) {
+	pub := &wireReader{buf: public}
+	priv := &wireReader{buf: private}
+	if name := string(pub.bytes()); name != algo {
+		return nil, fmt.Errorf("ppk: public key type %q does not match %q", name, algo)
+	}
+	switch algo {
+	case ssh.KeyAlgoRSA:
+		e, n := pub.mpint(), pub.mpint()
+		d, p, q := priv.mpint(), priv.mpint(), priv.mpint()
+		if pub.err != nil || priv.err != nil {
+			return nil, fmt.Errorf("ppk: truncated RSA key")
+		}
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: int(e.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err := key.Validate(); err != nil {
+			return nil, fmt.Errorf("ppk: %w", err)
+		}
+		key.Precompute()
+		return key, nil
+	case ssh.KeyAlgoDSA:
+		p, q, g, y := pub.mpint(), pub.mpint(), pub.mpint(), pub.mpint()
+		x := priv.mpint()
+		if pub.err != nil || priv.err != nil {
+			return nil, fmt.Errorf("ppk: truncated DSA key")
+		}
+		return &dsa.PrivateKey{
+			PublicKey: dsa.PublicKey{Parameters: dsa.Parameters{P: p, Q: q, G: g}, Y: y},
+			X:         x,
+		}, nil
+	case ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521:
+		_ = pub.bytes() // curve name
+		_ = pub.bytes() // public point, recomputed from the scalar
+		d := priv.mpint()
+		if pub.err != nil || priv.err != nil {
+			return nil, fmt.Errorf("ppk: truncated ECDSA key")
+		}
+		var curve elliptic.Curve
+		var ecurve ecdh.Curve
+		switch algo {
+		case ssh.KeyAlgoECDSA256:
+			curve, ecurve = elliptic.P256(), ecdh.P256()
+		case ssh.KeyAlgoECDSA384:
+			curve, ecurve = elliptic.P384(), ecdh.P384()
+		default:
+			curve, ecurve = elliptic.P521(), ecdh.P521()
+		}
+		scalar := d.FillBytes(make([]byte, (curve.Params().BitSize+7)/8))
+		ek, err := ecurve.NewPrivateKey(scalar)
+		if err != nil {
+			return nil, fmt.Errorf("ppk: %w", err)
+		}
+		point := ek.PublicKey().Bytes()
+		size := (len(point) - 1) / 2
+		return &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: curve,
+				X:     new(big.Int).SetBytes(point[1 : 1+size]),
+				Y:     new(big.Int).SetBytes(point[1+size:]),
+			},
+			D: d,
+		}, nil
+	case ssh.KeyAlgoED25519:
+		pk := pub.bytes()
+		seed := priv.bytes()
+		if pub.err != nil || priv.err != nil || len(pk) != ed25519.PublicKeySize || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("ppk: malformed Ed25519 key")
+		}
+		key := ed25519.NewKeyFromSeed(seed)
+		if !bytes.Equal(key.Public().(ed25519.PublicKey), pk) {
+			return nil, fmt.Errorf("ppk: Ed25519 public key mismatch")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("ppk: unsupported key type %s", algo)
+	}
+}
+
+// wireReader reads SSH wire-format strings and mpints, remembering the
+// first error.
+type wireReader struct {
+	buf []byte
+	err error
+}
+
+func (r *wireReader) bytes() []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.buf) < 4 {
+		r.err = fmt.Errorf("short buffer")
+		return nil
+	}
+	n := binary.BigEndian.Uint32(r.buf)
+	if uint64(len(r.buf)-4) < uint64(n) {
+		r.err = fmt.Errorf("short buffer")
+		return nil
+	}
+	out := r.buf[4 : 4+n]
+	r.buf = r.buf[4+n:]
+	return out
+}
+
+func (r *wireReader) mpint() *big.Int {
+	return new(big.Int).SetBytes(r.bytes())
+}
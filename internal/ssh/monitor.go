@@ -1 +1,420 @@
 package ssh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Mnitoer samples remote host metrics over a dedicated exec channel, so the
+// interactive shell is never touched.
+type Mnitoer struct {
+	mu   sync.Mutex
+	sess *ssh.Session
+	done chan struct{}
+}
+
+// CPUStat is CPU utilisation in percent over the last sample interval.
+type CPUStat struct {
+	Usage   float64   `json:"usage"`
+	User    float64   `json:"user"`
+	System  float64   `json:"system"`
+	IOWait  float64   `json:"iowait"`
+	Steal   float64   `json:"steal"`
+	Cores   int       `json:"cores"`
+	PerCore []float64 `json:"perCore,omitempty"`
+}
+
+// MemStat is memory usage in bytes from /proc/meminfo.
+type MemStat struct {
+	Total       uint64  `json:"total"`
+	Available   uint64  `json:"available"`
+	Used        uint64  `json:"used"`
+	Free        uint64  `json:"free"`
+	Buffers     uint64  `json:"buffers"`
+	Cached      uint64  `json:"cached"`
+	SwapTotal   uint64  `json:"swapTotal"`
+	SwapFree    uint64  `json:"swapFree"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+// LoadStat is /proc/loadavg.
+type LoadStat struct {
+	Load1   float64 `json:"load1"`
+	Load5   float64 `json:"load5"`
+	Load15  float64 `json:"load15"`
+	Running int     `json:"running"`
+	Total   int     `json:"total"`
+}
+
+// DiskStat is one filesystem from df, sizes in bytes.
+type DiskStat struct {
+	Filesystem  string  `json:"filesystem"`
+	Mount       string  `json:"mount"`
+	Size        uint64  `json:"size"`
+	Used        uint64  `json:"used"`
+	Avail       uint64  `json:"avail"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+// NetStat is one interface from /proc/net/dev with rates in bytes/s.
+type NetStat struct {
+	Iface   string  `json:"iface"`
+	RxBytes uint64  `json:"rxBytes"`
+	TxBytes uint64  `json:"txBytes"`
+	RxRate  float64 `json:"rxRate"`
+	TxRate  float64 `json:"txRate"`
+}
+
+// Metrics is one sample of the remote host. CPU is nil on the first sample,
+// which has no earlier counters to compare against.
+type Metrics struct {
+	Time  time.Time  `json:"time"`
+	CPU   *CPUStat   `json:"cpu,omitempty"`
+	Mem   MemStat    `json:"mem"`
+	Load  LoadStat   `json:"load"`
+	Disks []DiskStat `json:"disks,omitempty"`
+	Net   []NetStat  `json:"net,omitempty"`
+}
+
+// cpuTimes are the jiffy counters of one /proc/stat cpu line.
+type cpuTimes struct {
+	user, nice, system, idle, iowait, irq, softirq, steal uint64
+}
+
+func (c cpuTimes) total() uint64 {
+	return c.user + c.nice + c.system + c.idle + c.iowait + c.irq + c.softirq + c.steal
+}
+
+// monitorScript prints one marker-delimited sample every interval seconds.
+const monitorScript = `export LC_ALL=C; while :; do ` +
+	`echo @@stat; cat /proc/stat; ` +
+	`echo @@meminfo; cat /proc/meminfo; ` +
+	`echo @@loadavg; cat /proc/loadavg; ` +
+	`echo @@netdev; cat /proc/net/dev; ` +
+	`echo @@df; df -kP 2>/dev/null; ` +
+	`echo @@end; sleep %d; done`
+
+// StartMonitor starts sampling s every interval (minimum one second) and
+// calls emit with each sample. A running monitor is replaced.
+func StartMonitor(s *Sshobject, interval time.Duration, emit func(Metrics)) error {
+	if s == nil || s.client == nil {
+		return fmt.Errorf("ssh client not started")
+	}
+	secs := int(interval / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	StopMonitor(s)
+
+	sess, err := s.client.NewSession()
+	if err != nil {
+		return err
+	}
+	out, err := sess.StdoutPipe()
+	if err != nil {
+		_ = sess.Close()
+		return err
+	}
+	if err := sess.Start(fmt.Sprintf(monitorScript, secs)); err != nil {
+		_ = sess.Close()
+		return err
+	}
+	done := make(chan struct{})
+	s.M.mu.Lock()
+	s.M.sess, s.M.done = sess, done
+	s.M.mu.Unlock()
+	LogInfof("Monitor on %s started (%ds)", s.Host, secs)
+
+	go func() {
+		defer close(done)
+		readSamples(out, emit)
+		_ = sess.Close()
+		s.M.mu.Lock()
+		if s.M.sess == sess {
+			s.M.sess, s.M.done = nil, nil
+		}
+		s.M.mu.Unlock()
+		LogInfof("Monitor on %s stopped", s.Host)
+	}()
+	return nil
+}
+
+// StopMonitor stops the sampler of s if one is running.
+func StopMonitor(s *Sshobject) {
+	if s == nil {
+		return
+	}
+	s.M.mu.Lock()
+	sess, done := s.M.sess, s.M.done
+	s.M.sess, s.M.done = nil, nil
+	s.M.mu.Unlock()
+	if sess == nil {
+		return
+	}
+	_ = sess.Close()
+	<-done
+}
+
+// MonitorRunning reports whether a sampler is active on s.
+func MonitorRunning(s *Sshobject) bool {
+	if s == nil {
+		return false
+	}
+	s.M.mu.Lock()
+	defer s.M.mu.Unlock()
+	return s.M.sess != nil
+}
+
+// readSamples splits the sampler output into sections and emits a Metrics
+// for every complete sample until r ends.
+func readSamples(r io.Reader, emit func(Metrics)) {
+	var prev *sampleState
+	sections := map[string][]string{}
+	section := ""
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "@@") {
+			section = strings.TrimPrefix(line, "@@")
+			if section != "end" {
+				sections[section] = nil
+				continue
+			}
+			m, st := buildMetrics(sections, prev, time.Now())
+			prev = st
+			if emit != nil {
+				emit(m)
+			}
+			sections = map[string][]string{}
+			section = ""
+			continue
+		}
+		if section != "" {
+			sections[section] = append(sections[section], line)
+		}
+	}
+}
+
+// sampleState carries the counters needed to turn the next sample into rates.
+type sampleState struct {
+	at    time.Time
+	cpu   cpuTimes
+	cores []cpuTimes
+	net   map[string][2]uint64
+}
+
+func buildMetrics(sections map[string][]string, prev *sampleState, now time.Time) (Metrics, *sampleState) {
+	m := Metrics{Time: now.UTC()}
+	cpu, cores := parseProcStat(sections["stat"])
+	st := &sampleState{at: now, cpu: cpu, cores: cores, net: map[string][2]uint64{}}
+
+	if prev != nil {
+		c := cpuPercent(prev.cpu, cpu)
+		c.Cores = len(cores)
+		for i, core := range cores {
+			var pb cpuTimes
+			if i < len(prev.cores) {
+				pb = prev.cores[i]
+			}
+			c.PerCore = append(c.PerCore, cpuPercent(pb, core).Usage)
+		}
+		m.CPU = &c
+	}
+
+	m.Mem = parseMeminfo(sections["meminfo"])
+	m.Load = parseLoadavg(sections["loadavg"])
+	m.Disks = parseDf(sections["df"])
+
+	m.Net = parseNetDev(sections["netdev"])
+	for i := range m.Net {
+		n := &m.Net[i]
+		st.net[n.Iface] = [2]uint64{n.RxBytes, n.TxBytes}
+		if prev == nil {
+			continue
+		}
+		old, ok := prev.net[n.Iface]
+		secs := now.Sub(prev.at).Seconds()
+		if !ok || secs <= 0 || n.RxBytes < old[0] || n.TxBytes < old[1] {
+			continue
+		}
+		n.RxRate = float64(n.RxBytes-old[0]) / secs
+		n.TxRate = float64(n.TxBytes-old[1]) / secs
+	}
+	return m, st
+}
+
+// cpuPercent computes utilisation between two counter snapshots.
+func cpuPercent(a, b cpuTimes) CPUStat {
+	dt := float64(b.total()) - float64(a.total())
+	if dt <= 0 {
+		return CPUStat{}
+	}
+	pct := func(x, y uint64) float64 {
+		if y < x {
+			return 0
+		}
+		return float64(y-x) / dt * 100
+	}
+	idle := pct(a.idle+a.iowait, b.idle+b.iowait)
+	return CPUStat{
+		Usage:  100 - idle,
+		User:   pct(a.user+a.nice, b.user+b.nice),
+		System: pct(a.system+a.irq+a.softirq, b.system+b.irq+b.softirq),
+		IOWait: pct(a.iowait, b.iowait),
+		Steal:  pct(a.steal, b.steal),
+	}
+}
+
+// parseProcStat returns the aggregate and per-core counters of /proc/stat.
+func parseProcStat(lines []string) (cpuTimes, []cpuTimes) {
+	var all cpuTimes
+	var cores []cpuTimes
+	for _, line := range lines {
+		f := strings.Fields(line)
+		if len(f) < 5 || !strings.HasPrefix(f[0], "cpu") {
+			continue
+		}
+		v := make([]uint64, 8)
+		for i := 0; i < 8 && i+1 < len(f); i++ {
+			v[i], _ = strconv.ParseUint(f[i+1], 10, 64)
+		}
+		t := cpuTimes{v[0], v[1], v[2], v[3], v[4], v[5], v[6], v[7]}
+		if f[0] == "cpu" {
+			all = t
+		} else {
+			cores = append(cores, t)
+		}
+	}
+	return all, cores
+}
+
+// parseMeminfo reads /proc/meminfo; values there are in KiB.
+func parseMeminfo(lines []string) MemStat {
+	kv := map[string]uint64{}
+	for _, line := range lines {
+		key, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		f := strings.Fields(rest)
+		if len(f) == 0 {
+			continue
+		}
+		n, err := strconv.ParseUint(f[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		kv[key] = n * 1024
+	}
+	m := MemStat{
+		Total:     kv["MemTotal"],
+		Free:      kv["MemFree"],
+		Buffers:   kv["Buffers"],
+		Cached:    kv["Cached"] + kv["SReclaimable"],
+		SwapTotal: kv["SwapTotal"],
+		SwapFree:  kv["SwapFree"],
+	}
+	if v, ok := kv["MemAvailable"]; ok {
+		m.Available = v
+	} else {
+		// Kernels before 3.14 lack MemAvailable.
+		m.Available = m.Free + m.Buffers + m.Cached
+	}
+	if m.Total > m.Available {
+		m.Used = m.Total - m.Available
+	}
+	if m.Total > 0 {
+		m.UsedPercent = float64(m.Used) / float64(m.Total) * 100
+	}
+	return m
+}
+
+// parseLoadavg reads "0.52 0.58 0.59 2/1234 5678".
+func parseLoadavg(lines []string) LoadStat {
+	var l LoadStat
+	if len(lines) == 0 {
+		return l
+	}
+	f := strings.Fields(lines[0])
+	if len(f) < 4 {
+		return l
+	}
+	l.Load1, _ = strconv.ParseFloat(f[0], 64)
+	l.Load5, _ = strconv.ParseFloat(f[1], 64)
+	l.Load15, _ = strconv.ParseFloat(f[2], 64)
+	if r, t, ok := strings.Cut(f[3], "/"); ok {
+		l.Running, _ = strconv.Atoi(r)
+		l.Total, _ = strconv.Atoi(t)
+	}
+	return l
+}
+
+// parseNetDev reads /proc/net/dev, skipping the two header lines and lo.
+func parseNetDev(lines []string) []NetStat {
+	var out []NetStat
+	for _, line := range lines {
+		name, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		f := strings.Fields(rest)
+		if name == "" || name == "lo" || len(f) < 9 {
+			continue
+		}
+		rx, err1 := strconv.ParseUint(f[0], 10, 64)
+		tx, err2 := strconv.ParseUint(f[8], 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		out = append(out, NetStat{Iface: name, RxBytes: rx, TxBytes: tx})
+	}
+	return out
+}
+
+// pseudoFS are df rows that say nothing about real storage.
+var pseudoFS = map[string]bool{
+	"tmpfs": true, "devtmpfs": true, "udev": true, "none": true,
+	"shm": true, "overlay": true, "efivarfs": true,
+}
+
+// parseDf reads POSIX `df -kP` output.
+func parseDf(lines []string) []DiskStat {
+	var out []DiskStat
+	for i, line := range lines {
+		f := strings.Fields(line)
+		if i == 0 && len(f) > 0 && f[0] == "Filesystem" {
+			continue
+		}
+		if len(f) < 6 {
+			continue
+		}
+		// Mount points may contain spaces; the first five columns do not.
+		fs, mount := f[0], strings.Join(f[5:], " ")
+		if pseudoFS[fs] || strings.HasPrefix(mount, "/snap/") ||
+			strings.HasPrefix(mount, "/sys") || strings.HasPrefix(mount, "/proc") ||
+			strings.HasPrefix(mount, "/dev") || strings.HasPrefix(mount, "/run") {
+			continue
+		}
+		size, err1 := strconv.ParseUint(f[1], 10, 64)
+		used, err2 := strconv.ParseUint(f[2], 10, 64)
+		avail, err3 := strconv.ParseUint(f[3], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		d := DiskStat{Filesystem: fs, Mount: mount, Size: size * 1024, Used: used * 1024, Avail: avail * 1024}
+		if used+avail > 0 {
+			d.UsedPercent = float64(used) / float64(used+avail) * 100
+		}
+		out = append(out, d)
+	}
+	return out
+}
@@ -0,0 +1,180 @@
+package ssh
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Fixtures in the layout of Linux /proc files and POSIX df -kP output.
+const (
+	fixtureStat1 = `cpu  10132153 290696 3084719 46828483 16683 0 25195 0 0 0
+cpu0 1393280 32966 572056 13366426 6950 0 17750 0 0 0
+cpu1 1335626 30934 564520 13393547 1917 0 4126 0 0 0
+intr 199292844 46 9 0 0 0 0 0 0 1 0 0 0 0 0 0 0 0
+ctxt 322580563
+btime 1694563020
+processes 134574
+procs_running 2
+procs_blocked 0`
+
+	fixtureStat2 = `cpu  10132353 290696 3084819 46828883 16783 0 25195 0 0 0
+cpu0 1393380 32966 572106 13366626 6950 0 17750 0 0 0
+cpu1 1335726 30934 564570 13393747 2017 0 4126 0 0 0
+intr 199293844 46 9 0 0 0 0 0 0 1 0 0 0 0 0 0 0 0`
+
+	fixtureMeminfo = `MemTotal:        8041124 kB
+MemFree:          512344 kB
+MemAvailable:    4823456 kB
+Buffers:          220100 kB
+Cached:          3648672 kB
+SwapCached:            0 kB
+SReclaimable:     301220 kB
+SwapTotal:       2097148 kB
+SwapFree:        2097148 kB
+HugePages_Total:       0`
+
+	fixtureLoadavg = `0.52 0.58 0.59 2/1234 5678`
+
+	fixtureNetDev = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo: 2776770   11307    0    0    0     0          0         0  2776770   11307    0    0    0     0       0          0
+  eth0: 1215645    2751    0    0    0     0          0         0  1782404    4324    0    0    0   427       0          0
+docker0:       0       0    0    0    0     0          0         0      574       7    0    0    0     0       0          0`
+
+	fixtureNetDev2 = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo: 2776770   11307    0    0    0     0          0         0  2776770   11307    0    0    0     0       0          0
+  eth0: 1225645    2760    0    0    0     0          0         0  1787404    4330    0    0    0   427       0          0
+docker0:       0       0    0    0    0     0          0         0      574       7    0    0    0     0       0          0`
+
+	fixtureDf = `Filesystem     1024-blocks      Used Available Capacity Mounted on
+udev               4000000         0   4000000       0% /dev
+tmpfs               804112      1772    802340       1% /run
+/dev/sda1         61255492  24156340  37082768      40% /
+tmpfs              4020560         0   4020560       0% /dev/shm
+/dev/loop0           64896     64896         0     100% /snap/core20/1974
+/dev/sdb1        103080224  51540112  46281232      53% /mnt/My Data`
+)
+
+func fixtureLines(s string) []string { return strings.Split(s, "\n") }
+
+func near(a, b float64) bool { return math.Abs(a-b) < 0.01 }
+
+func TestParseProcStat(t *testing.T) {
+	all, cores := parseProcStat(fixtureLines(fixtureStat1))
+	want := cpuTimes{10132153, 290696, 3084719, 46828483, 16683, 0, 25195, 0}
+	if all != want {
+		t.Fatalf("aggregate = %+v, want %+v", all, want)
+	}
+	if len(cores) != 2 {
+		t.Fatalf("cores = %d, want 2", len(cores))
+	}
+	if cores[1].idle != 13393547 {
+		t.Errorf("cpu1 idle = %d", cores[1].idle)
+	}
+}
+
+func TestParseMeminfo(t *testing.T) {
+	m := parseMeminfo(fixtureLines(fixtureMeminfo))
+	if m.Total != 8041124*1024 || m.Available != 4823456*1024 {
+		t.Fatalf("total/available = %d/%d", m.Total, m.Available)
+	}
+	if m.Cached != (3648672+301220)*1024 {
+		t.Errorf("cached = %d", m.Cached)
+	}
+	if m.Used != (8041124-4823456)*1024 {
+		t.Errorf("used = %d", m.Used)
+	}
+	if !near(m.UsedPercent, 40.02) {
+		t.Errorf("used percent = %.2f", m.UsedPercent)
+	}
+
+	// Without MemAvailable the estimate is free + buffers + cached.
+	old := parseMeminfo(fixtureLines("MemTotal: 1000 kB\nMemFree: 100 kB\nBuffers: 50 kB\nCached: 250 kB"))
+	if old.Available != 400*1024 {
+		t.Errorf("fallback available = %d", old.Available)
+	}
+}
+
+func TestParseLoadavg(t *testing.T) {
+	l := parseLoadavg(fixtureLines(fixtureLoadavg))
+	want := LoadStat{Load1: 0.52, Load5: 0.58, Load15: 0.59, Running: 2, Total: 1234}
+	if l != want {
+		t.Fatalf("load = %+v, want %+v", l, want)
+	}
+	if l := parseLoadavg(nil); l != (LoadStat{}) {
+		t.Errorf("empty = %+v", l)
+	}
+}
+
+func TestParseNetDev(t *testing.T) {
+	n := parseNetDev(fixtureLines(fixtureNetDev))
+	if len(n) != 2 {
+		t.Fatalf("got %d interfaces, want 2 (lo skipped): %+v", len(n), n)
+	}
+	if n[0] != (NetStat{Iface: "eth0", RxBytes: 1215645, TxBytes: 1782404}) {
+		t.Errorf("eth0 = %+v", n[0])
+	}
+	if n[1].Iface != "docker0" || n[1].TxBytes != 574 {
+		t.Errorf("docker0 = %+v", n[1])
+	}
+}
+
+func TestParseDf(t *testing.T) {
+	d := parseDf(fixtureLines(fixtureDf))
+	if len(d) != 2 {
+		t.Fatalf("got %d filesystems, want 2: %+v", len(d), d)
+	}
+	if d[0].Mount != "/" || d[0].Size != 61255492*1024 || d[0].Used != 24156340*1024 {
+		t.Errorf("root = %+v", d[0])
+	}
+	if d[1].Mount != "/mnt/My Data" {
+		t.Errorf("mount with space = %q", d[1].Mount)
+	}
+	if !near(d[0].UsedPercent, 39.45) {
+		t.Errorf("root used percent = %.2f", d[0].UsedPercent)
+	}
+}
+
+func TestBuildMetrics(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := map[string][]string{
+		"stat":    fixtureLines(fixtureStat1),
+		"meminfo": fixtureLines(fixtureMeminfo),
+		"loadavg": fixtureLines(fixtureLoadavg),
+		"netdev":  fixtureLines(fixtureNetDev),
+		"df":      fixtureLines(fixtureDf),
+	}
+	m, st := buildMetrics(first, nil, t0)
+	if m.CPU != nil {
+		t.Errorf("first sample has CPU %+v; want none until two samples", m.CPU)
+	}
+	if m.Net[0].RxRate != 0 {
+		t.Errorf("first sample rx rate = %f", m.Net[0].RxRate)
+	}
+	if m.Mem.Total == 0 || m.Load.Total != 1234 || len(m.Disks) != 2 {
+		t.Errorf("first sample = %+v", m)
+	}
+
+	second := map[string][]string{
+		"stat":   fixtureLines(fixtureStat2),
+		"netdev": fixtureLines(fixtureNetDev2),
+	}
+	m, _ = buildMetrics(second, st, t0.Add(2*time.Second))
+	if m.CPU == nil {
+		t.Fatal("second sample has no CPU")
+	}
+	// total +800 jiffies: user +200, system +100, idle +400, iowait +100
+	if !near(m.CPU.Usage, 37.5) || !near(m.CPU.User, 25) ||
+		!near(m.CPU.System, 12.5) || !near(m.CPU.IOWait, 12.5) {
+		t.Errorf("cpu = %+v", *m.CPU)
+	}
+	if m.CPU.Cores != 2 || len(m.CPU.PerCore) != 2 || !near(m.CPU.PerCore[0], 42.86) {
+		t.Errorf("per core = %v", m.CPU.PerCore)
+	}
+	if m.Net[0].RxRate != 5000 || m.Net[0].TxRate != 2500 {
+		t.Errorf("eth0 rates = %f/%f", m.Net[0].RxRate, m.Net[0].TxRate)
+	}
+}
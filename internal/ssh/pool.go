@@ -0,0 +1,232 @@
+package ssh
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sharedClient is one pooled connection, like an OpenSSH ControlMaster.
+// Every Sshobject with the same ShareKey holds a reference; the connection
+// closes when the last one is released.
+type sharedClient struct {
+	key    string
+	ready  chan struct{} // closed once the owner finished dialing
+	closed chan struct{} // closed when the connection ends
+	client *ssh.Client
+	hops   []*ssh.Client
+	err    error
+	hostCA string
+	refs   int // guarded by clientPool.mu
+
+	// latency is the last keepalive round-trip time, reported to every
+	// holder in subs.
+	latency atomic.Int64
+
+	mu       sync.Mutex
+	agentFwd bool
+	x11      *x11Forwarder
+	subs     map[*Sshobject]struct{}
+}
+
+// subscribe makes s receive the connection's keepalive round-trip times.
+func (sc *sharedClient) subscribe(s *Sshobject) {
+	sc.mu.Lock()
+	if sc.subs == nil {
+		sc.subs = make(map[*Sshobject]struct{})
+	}
+	sc.subs[s] = struct{}{}
+	sc.mu.Unlock()
+}
+
+func (sc *sharedClient) unsubscribe(s *Sshobject) {
+	sc.mu.Lock()
+	delete(sc.subs, s)
+	sc.mu.Unlock()
+}
+
+// reportLatency records rtt and passes it to the OnLatency of every holder.
+func (sc *sharedClient) reportLatency(rtt time.Duration) {
+	sc.latency.Store(int64(rtt))
+	sc.mu.Lock()
+	cbs := make([]func(time.Duration), 0, len(sc.subs))
+	for s := range sc.subs {
+		if s.OnLatency != nil {
+			cbs = append(cbs, s.OnLatency)
+		}
+	}
+	sc.mu.Unlock()
+	for _, cb := range cbs {
+		cb(rtt)
+	}
+}
+
+func (sc *sharedClient) alive() bool {
+	select {
+	case <-sc.closed:
+		return false
+	default:
+		return sc.err == nil
+	}
+}
+
+type clientPool struct {
+	mu sync.Mutex
+	m  map[string]*sharedClient
+}
+
+// sharedClients is the process-wide connection pool.
+var sharedClients clientPool
+
+// acquire returns a live connection for key with a reference taken. When
+// owner is true there was none and the caller must dial and call finish.
+// Concurrent callers wait for the owner, so a host is authenticated once.
+func (p *clientPool) acquire(key string) (sc *sharedClient, owner bool) {
+	for {
+		p.mu.Lock()
+		sc = p.m[key]
+		if sc == nil {
+			sc = &sharedClient{key: key, ready: make(chan struct{}), closed: make(chan struct{}), refs: 1}
+			if p.m == nil {
+				p.m = make(map[string]*sharedClient)
+			}
+			p.m[key] = sc
+			p.mu.Unlock()
+			return sc, true
+		}
+		sc.refs++
+		p.mu.Unlock()
+
+		<-sc.ready
+		if sc.alive() {
+			return sc, false
+		}
+		p.release(sc)
+		p.forget(sc)
+	}
+}
+
+// finish publishes the owner's dial result to waiting callers.
+func (p *clientPool) finish(sc *sharedClient, client *ssh.Client, hops []*ssh.Client, err error) {
+	sc.client, sc.hops, sc.err = client, hops, err
+	if err != nil {
+		p.forget(sc)
+		close(sc.closed)
+	} else {
+		go func() {
+			_ = client.Wait()
+			p.forget(sc)
+			close(sc.closed)
+		}()
+	}
+	close(sc.ready)
+}
+
+// forget removes sc from the pool so the next acquire dials afresh.
+func (p *clientPool) forget(sc *sharedClient) {
+	p.mu.Lock()
+	if p.m[sc.key] == sc {
+		delete(p.m, sc.key)
+	}
+	p.mu.Unlock()
+}
+
+// release drops one reference, closing the connection with the last one.
+func (p *clientPool) release(sc *sharedClient) {
+	p.mu.Lock()
+	sc.refs--
+	last := sc.refs == 0
+	if last && p.m[sc.key] == sc {
+		delete(p.m, sc.key)
+	}
+	p.mu.Unlock()
+	if !last {
+		return
+	}
+	if sc.client != nil {
+		_ = sc.client.Close()
+	}
+	closeClients(sc.hops)
+	LogInfof("Shared connection %s closed", sc.key)
+}
+
+// refCount returns the number of holders of sc.
+func (p *clientPool) refCount(sc *sharedClient) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return sc.refs
+}
+
+// Identity returns the ControlMaster-style identity of s: user, host, proxy,
+// jump chain and a fingerprint of the credentials of every node. Objects
+// with equal identities can share a connection. Proxy userinfo is left out
+// of the text, so the result is safe to log.
+func Identity(s *Sshobject) string {
+	if s == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(s.User + "@" + s.Host)
+	for _, hop := range s.Jumps {
+		if hop != nil {
+			b.WriteString(" via " + hop.User + "@" + hop.Host)
+		}
+	}
+	var proxyUser string
+	if s.P.URL != "" {
+		if u, err := url.Parse(s.P.URL); err == nil {
+			if u.User != nil {
+				proxyUser = u.User.String()
+			}
+			u.User = nil
+			b.WriteString(" proxy " + u.String())
+		} else {
+			proxyUser = s.P.URL
+			b.WriteString(" proxy ?")
+		}
+	}
+
+	// Secrets go through a keyed hash that is only stable within this
+	// process, so the fingerprint cannot be checked against a guess offline.
+	h := hmac.New(sha256.New, identityKey)
+	for _, node := range append([]*Sshobject{s}, s.Jumps...) {
+		if node != nil {
+			node.writeCredentials(h)
+		}
+	}
+	fmt.Fprintf(h, "proxy\x00%s\x00", proxyUser)
+	b.WriteString(" auth " + hex.EncodeToString(h.Sum(nil)[:8]))
+	return b.String()
+}
+
+// identityKey keys the credential fingerprint of Identity.
+var identityKey = func() []byte {
+	k := make([]byte, 32)
+	_, _ = rand.Read(k)
+	return k
+}()
+
+// writeCredentials writes what s authenticates with to h.
+func (s *Sshobject) writeCredentials(h io.Writer) {
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00", s.auth, s.User, s.Passwd)
+	if s.signer != nil {
+		h.Write(s.signer.PublicKey().Marshal())
+	}
+	if s.cert != nil {
+		h.Write(s.cert.Marshal())
+	}
+	if s.auth == AuthAgent {
+		io.WriteString(h, AgentSocket())
+	}
+	io.WriteString(h, "\x00")
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // PortForward tracks active forward listeners. Now package-global.
@@ -14,6 +16,7 @@ type PortForward struct {
 	locals   []*forwardTracker
 	remotes  []*forwardTracker
 	dynamics []*forwardTracker
+	proxies  []*forwardTracker
 }
 
 // GlobalPortForward is the package-wide manager tracking active forwards.
@@ -25,6 +28,11 @@ type forwardTracker struct {
 	conns    map[net.Conn]struct{}
 	closed   bool
 	wg       sync.WaitGroup
+
+	bytesIn, bytesOut atomic.Int64
+	total             atomic.Int64
+	lastErr           string
+	lastErrAt         time.Time
 }
 
 func newForwardTracker(listener net.Listener) *forwardTracker {
@@ -89,7 +97,7 @@ func (ft *forwardTracker) Close() error {
 }
 
 // LocalForward starts local port forwarding: localAddr => remoteAddr via SSH.
-func (s *Sshobject) LocalForward(localAddr, remoteAddr string) (func() error, error) {
+func (s *Sshobject) LocalForward(localAddr, remoteAddr string) (*Forwarder, error) {
 	if s.client == nil {
 		return nil, fmt.Errorf("ssh client not started")
 	}
@@ -97,6 +105,12 @@ func (s *Sshobject) LocalForward(localAddr, remoteAddr string) (func() error, er
 	if err != nil {
 		return nil, err
 	}
+	return s.localForward(ln, localAddr, "tcp", remoteAddr), nil
+}
+
+// localForward serves ln, dialing remoteAddr on the server for each
+// connection.
+func (s *Sshobject) localForward(ln net.Listener, localAddr, remoteNet, remoteAddr string) *Forwarder {
 	tracker := newForwardTracker(ln)
 	GlobalPortForward.locals = append(GlobalPortForward.locals, tracker)
 	LogInfof("Local forward %s => %s started", localAddr, remoteAddr)
@@ -109,8 +123,10 @@ func (s *Sshobject) LocalForward(localAddr, remoteAddr string) (func() error, er
 				}
 				// Log and backoff briefly before retrying
 				LogErrorf("Local forward accept error: %v", err)
+				tracker.fail(err)
 				continue
 			}
+			lc = tracker.client(lc)
 			if !tracker.trackConn(lc) {
 				continue
 			}
@@ -120,9 +136,10 @@ func (s *Sshobject) LocalForward(localAddr, remoteAddr string) (func() error, er
 				defer tracker.untrackConn(c)
 				defer c.Close()
 
-				rc, err := s.client.Dial("tcp", remoteAddr)
+				rc, err := s.client.Dial(remoteNet, remoteAddr)
 				if err != nil {
 					LogErrorf("Local forward dial remote failed: %v", err)
+					tracker.fail(err)
 					return
 				}
 				if !tracker.trackConn(rc) {
@@ -142,11 +159,11 @@ func (s *Sshobject) LocalForward(localAddr, remoteAddr string) (func() error, er
 
 		return tracker.Close()
 	}
-	return cancel, nil
+	return &Forwarder{tracker: tracker, stop: cancel}
 }
 
 // RemoteForward starts remote port forwarding: remoteBind => localTarget via SSH.
-func (s *Sshobject) RemoteForward(remoteBind, localTarget string) (func() error, error) {
+func (s *Sshobject) RemoteForward(remoteBind, localTarget string) (*Forwarder, error) {
 	if s.client == nil {
 		return nil, fmt.Errorf("ssh client not started")
 	}
@@ -154,6 +171,12 @@ func (s *Sshobject) RemoteForward(remoteBind, localTarget string) (func() error,
 	if err != nil {
 		return nil, err
 	}
+	return s.remoteForward(rln, remoteBind, "tcp", localTarget), nil
+}
+
+// remoteForward serves the server-side listener rln, dialing localTarget
+// for each connection.
+func (s *Sshobject) remoteForward(rln net.Listener, remoteBind, localNet, localTarget string) *Forwarder {
 	tracker := newForwardTracker(rln)
 	GlobalPortForward.remotes = append(GlobalPortForward.remotes, tracker)
 	LogInfof("Remote forward %s => %s started", remoteBind, localTarget)
@@ -167,8 +190,10 @@ func (s *Sshobject) RemoteForward(remoteBind, localTarget string) (func() error,
 				}
 				// The underlying ssh listener may not expose net.ErrClosed reliably.
 				LogErrorf("Remote forward accept error: %v", err)
+				tracker.fail(err)
 				return
 			}
+			rc = tracker.client(rc)
 			if !tracker.trackConn(rc) {
 				continue
 			}
@@ -178,9 +203,10 @@ func (s *Sshobject) RemoteForward(remoteBind, localTarget string) (func() error,
 				defer tracker.untrackConn(c)
 				defer c.Close()
 				LogInfof("处理一个连接")
-				lc, err := net.Dial("tcp", localTarget)
+				lc, err := net.Dial(localNet, localTarget)
 				if err != nil {
 					LogErrorf("Remote forward dial local failed: %v", err)
+					tracker.fail(err)
 					return
 				}
 				if !tracker.trackConn(lc) {
@@ -199,21 +225,35 @@ func (s *Sshobject) RemoteForward(remoteBind, localTarget string) (func() error,
 		LogInfof("Remote forward %s => %s stopped", remoteBind, localTarget)
 		return tracker.Close()
 	}
-	return cancel, nil
+	return &Forwarder{tracker: tracker, stop: cancel}
 }
 
 // DynamicForward starts a SOCKS5 proxy on localSocks that tunnels via SSH.
-func (s *Sshobject) DynamicForward(localSocks string) (func() error, error) {
+func (s *Sshobject) DynamicForward(localSocks string) (*Forwarder, error) {
+	return s.DynamicForwardWithOptions(localSocks, SocksOptions{})
+}
+
+// DynamicForwardWithOptions is DynamicForward with authentication and UDP
+// relay settings.
+func (s *Sshobject) DynamicForwardWithOptions(localSocks string, opts SocksOptions) (*Forwarder, error) {
+	return s.serveDynamic(localSocks, "Dynamic SOCKS5", &GlobalPortForward.dynamics, func(c net.Conn, tracker *forwardTracker) {
+		s.handleSocks5(c, tracker, opts)
+	})
+}
+
+// serveDynamic listens on localAddr and hands each accepted connection to
+// handle, which owns it and must untrack and close it.
+func (s *Sshobject) serveDynamic(localAddr, name string, list *[]*forwardTracker, handle func(net.Conn, *forwardTracker)) (*Forwarder, error) {
 	if s.client == nil {
 		return nil, fmt.Errorf("ssh client not started")
 	}
-	ln, err := net.Listen("tcp", localSocks)
+	ln, err := net.Listen("tcp", localAddr)
 	if err != nil {
 		return nil, err
 	}
 	tracker := newForwardTracker(ln)
-	GlobalPortForward.dynamics = append(GlobalPortForward.dynamics, tracker)
-	LogInfof("Dynamic SOCKS5 %s started", localSocks)
+	*list = append(*list, tracker)
+	LogInfof("%s %s started", name, localAddr)
 	go func() {
 		for {
 			c, err := ln.Accept()
@@ -221,24 +261,26 @@ func (s *Sshobject) DynamicForward(localSocks string) (func() error, error) {
 				if tracker.isClosed() || errors.Is(err, net.ErrClosed) {
 					return
 				}
-				LogErrorf("Dynamic SOCKS accept error: %v", err)
+				LogErrorf("%s accept error: %v", name, err)
+				tracker.fail(err)
 				continue
 			}
+			c = tracker.client(c)
 			if !tracker.trackConn(c) {
 				continue
 			}
 			tracker.wg.Add(1)
 			go func(conn net.Conn) {
 				defer tracker.wg.Done()
-				s.handleSocks5(conn, tracker)
+				handle(conn, tracker)
 			}(c)
 		}
 	}()
 	cancel := func() error {
-		LogInfof("Dynamic SOCKS5 %s stopped", localSocks)
+		LogInfof("%s %s stopped", name, localAddr)
 		return tracker.Close()
 	}
-	return cancel, nil
+	return &Forwarder{tracker: tracker, stop: cancel}, nil
 }
 
 func proxyPipe(a, b net.Conn) {
@@ -259,90 +301,16 @@ func closeWrite(c net.Conn) {
 	}
 }
 
-// Minimal SOCKS5 handler: no auth, CONNECT only.
-func (s *Sshobject) handleSocks5(c net.Conn, tracker *forwardTracker) {
+// handleSocks5 serves one SOCKS5 client connection.
+func (s *Sshobject) handleSocks5(c net.Conn, tracker *forwardTracker, opts SocksOptions) {
 	if tracker != nil {
 		defer tracker.untrackConn(c)
 	}
 	defer c.Close()
 	br := bufio.NewReader(c)
-	bw := bufio.NewWriter(c)
-
-	// handshake
-	ver, _ := br.ReadByte()
-	if ver != 0x05 {
-		return
-	}
-	nm, _ := br.ReadByte()
-	for i := 0; i < int(nm); i++ {
-		_, _ = br.ReadByte()
-	}
-	_, _ = bw.Write([]byte{0x05, 0x00}) // no-auth
-	_ = bw.Flush()
-
-	// request: VER CMD RSV ATYP DST.ADDR DST.PORT
-	header := make([]byte, 4)
-	if _, err := io.ReadFull(br, header); err != nil {
-		return
-	}
-	if header[0] != 0x05 || header[1] != 0x01 { // CONNECT only
-		bw.Write([]byte{0x05, 0x07, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
-		bw.Flush()
+	ver, err := br.ReadByte()
+	if err != nil || ver != socksVersion {
 		return
 	}
-	var host string
-	switch header[3] {
-	case 0x01: // IPv4
-		addr := make([]byte, 4)
-		if _, err := io.ReadFull(br, addr); err != nil {
-			return
-		}
-		host = net.IP(addr).String()
-	case 0x03: // Domain
-		ln, _ := br.ReadByte()
-		d := make([]byte, int(ln))
-		if _, err := io.ReadFull(br, d); err != nil {
-			return
-		}
-		host = string(d)
-	case 0x04: // IPv6
-		addr := make([]byte, 16)
-		if _, err := io.ReadFull(br, addr); err != nil {
-			return
-		}
-		host = net.IP(addr).String()
-	default:
-		bw.Write([]byte{0x05, 0x08, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
-		bw.Flush()
-		return
-	}
-	portb := make([]byte, 2)
-	if _, err := io.ReadFull(br, portb); err != nil {
-		return
-	}
-	port := int(portb[0])<<8 | int(portb[1])
-	target := net.JoinHostPort(host, fmt.Sprintf("%d", port))
-
-	rc, err := s.client.Dial("tcp", target)
-	if err != nil {
-		LogErrorf("SOCKS dial via SSH failed: %v", err)
-		bw.Write([]byte{0x05, 0x01, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
-		bw.Flush()
-		return
-	}
-	if tracker != nil && !tracker.trackConn(rc) {
-		rc.Close()
-		return
-	}
-	defer func() {
-		if tracker != nil {
-			tracker.untrackConn(rc)
-		}
-		rc.Close()
-	}()
-
-	// success
-	bw.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
-	bw.Flush()
-	proxyPipe(c, rc)
+	s.serveSocks5(c, br, tracker, opts)
 }
@@ -0,0 +1,164 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Process is one row of the remote process table.
+type Process struct {
+	PID     int     `json:"pid"`
+	PPID    int     `json:"ppid"`
+	User    string  `json:"user"`
+	CPU     float64 `json:"cpu"`
+	Mem     float64 `json:"mem"`
+	RSS     uint64  `json:"rss"` // bytes
+	State   string  `json:"state"`
+	Elapsed string  `json:"elapsed"`
+	Command string  `json:"command"`
+}
+
+// ProcessQuery sorts and filters ListProcesses results.
+type ProcessQuery struct {
+	// SortBy is one of "cpu" (default), "mem", "rss", "pid", "user", "command".
+	SortBy string `json:"sortBy"`
+	// Asc sorts ascending; the default is descending.
+	Asc bool `json:"asc"`
+	// Filter keeps processes whose command or user contains it (case-insensitive).
+	Filter string `json:"filter"`
+	User   string `json:"user"`
+	Limit  int    `json:"limit"`
+}
+
+// processCommandTimeout bounds ps and kill so a wedged link cannot hang
+// the caller.
+const processCommandTimeout = 30 * time.Second
+
+// psCommand lists every process; args must stay last since it has spaces.
+const psCommand = `LC_ALL=C ps -eo pid=,ppid=,user=,pcpu=,pmem=,rss=,stat=,etime=,args=`
+
+// ListProcesses returns the remote process table filtered and sorted by q.
+func ListProcesses(s *Sshobject, q ProcessQuery) ([]Process, error) {
+	out, err := s.output(psCommand)
+	if err != nil {
+		return nil, fmt.Errorf("process list failed: %v", err)
+	}
+	procs := parsePs(out)
+
+	filter := strings.ToLower(strings.TrimSpace(q.Filter))
+	kept := procs[:0]
+	for _, p := range procs {
+		if q.User != "" && p.User != q.User {
+			continue
+		}
+		if filter != "" && !strings.Contains(strings.ToLower(p.Command), filter) &&
+			!strings.Contains(strings.ToLower(p.User), filter) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	sortProcesses(kept, q.SortBy, q.Asc)
+	if q.Limit > 0 && len(kept) > q.Limit {
+		kept = kept[:q.Limit]
+	}
+	return kept, nil
+}
+
+func parsePs(out []byte) []Process {
+	var procs []Process
+	for _, line := range strings.Split(string(out), "\n") {
+		f := strings.Fields(line)
+		if len(f) < 9 {
+			continue
+		}
+		pid, err := strconv.Atoi(f[0])
+		if err != nil {
+			continue
+		}
+		p := Process{PID: pid, User: f[2], State: f[6], Elapsed: f[7]}
+		p.PPID, _ = strconv.Atoi(f[1])
+		p.CPU, _ = strconv.ParseFloat(f[3], 64)
+		p.Mem, _ = strconv.ParseFloat(f[4], 64)
+		if kb, err := strconv.ParseUint(f[5], 10, 64); err == nil {
+			p.RSS = kb * 1024
+		}
+		p.Command = strings.Join(f[8:], " ")
+		procs = append(procs, p)
+	}
+	return procs
+}
+
+func sortProcesses(procs []Process, by string, asc bool) {
+	var less func(a, b *Process) bool
+	switch strings.ToLower(by) {
+	case "mem":
+		less = func(a, b *Process) bool { return a.Mem < b.Mem }
+	case "rss":
+		less = func(a, b *Process) bool { return a.RSS < b.RSS }
+	case "pid":
+		less = func(a, b *Process) bool { return a.PID < b.PID }
+	case "user":
+		less = func(a, b *Process) bool { return a.User < b.User }
+	case "command":
+		less = func(a, b *Process) bool { return a.Command < b.Command }
+	default:
+		less = func(a, b *Process) bool { return a.CPU < b.CPU }
+	}
+	sort.SliceStable(procs, func(i, j int) bool {
+		if asc {
+			return less(&procs[i], &procs[j])
+		}
+		return less(&procs[j], &procs[i])
+	})
+}
+
+// signalNames are the signals KillProcess accepts by name.
+var signalNames = map[string]bool{
+	"HUP": true, "INT": true, "QUIT": true, "KILL": true, "TERM": true,
+	"USR1": true, "USR2": true, "STOP": true, "CONT": true,
+}
+
+// KillProcess sends signal (a name like "TERM" or "SIGKILL", or a number;
+// empty means TERM) to pid on the remote host.
+func KillProcess(s *Sshobject, pid int, signal string) error {
+	if pid <= 0 {
+		return fmt.Errorf("invalid pid %d", pid)
+	}
+	sig := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(signal)), "SIG")
+	if sig == "" {
+		sig = "TERM"
+	}
+	if n, err := strconv.Atoi(sig); err == nil {
+		if n < 0 || n > 64 {
+			return fmt.Errorf("invalid signal %q", signal)
+		}
+	} else if !signalNames[sig] {
+		return fmt.Errorf("unsupported signal %q", signal)
+	}
+	if _, err := s.output(fmt.Sprintf("kill -%s %d", sig, pid)); err != nil {
+		return fmt.Errorf("kill %d failed: %v", pid, err)
+	}
+	LogInfof("Sent SIG%s to pid %d on %s", sig, pid, s.Host)
+	return nil
+}
+
+// output runs cmd on a fresh session and returns its stdout. A non-zero exit
+// is reported with the command's stderr. It gives up after
+// processCommandTimeout.
+func (s *Sshobject) output(cmd string) ([]byte, error) {
+	res, err := Exec(context.Background(), s, cmd, processCommandTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if res.ExitStatus != 0 || res.ExitSignal != "" {
+		if msg := strings.TrimSpace(res.Stderr); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, fmt.Errorf("exit status %d", res.ExitStatus)
+	}
+	return []byte(res.Stdout), nil
+}
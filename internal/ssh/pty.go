@@ -0,0 +1,156 @@
+package ssh
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultTerm is the TERM requested when PtyOptions.Term is empty.
+const DefaultTerm = "xterm-256color"
+
+// PtyOptions configures the PTY and program of interactive sessions.
+type PtyOptions struct {
+	// Term is the terminal type; empty means DefaultTerm.
+	Term string `json:"term,omitempty"`
+	// Modes overrides terminal modes by RFC 4254 name, e.g. "ECHO": 0 or
+	// "VINTR": 3.
+	Modes map[string]uint32 `json:"modes,omitempty"`
+	// Env is sent with Setenv; servers only accept names listed in their
+	// AcceptEnv, others are skipped with a log entry.
+	Env map[string]string `json:"env,omitempty"`
+	// Command runs instead of the login shell, e.g. "tmux new -A -s main".
+	Command string `json:"command,omitempty"`
+	// Subsystem starts a named subsystem instead of the shell. It takes
+	// precedence over Command.
+	Subsystem string `json:"subsystem,omitempty"`
+	// LoginShell starts the login shell, ignoring Command and Subsystem.
+	LoginShell bool `json:"loginShell,omitempty"`
+}
+
+// Merge returns o with the non-empty fields of over applied; Env and Modes
+// are merged key by key. An empty field in over means "unset", so Merge
+// cannot clear a field of o, e.g. drop Command to get the login shell back;
+// over.LoginShell clears Command and Subsystem for that.
+func (o PtyOptions) Merge(over PtyOptions) PtyOptions {
+	if over.LoginShell {
+		o.Command, o.Subsystem = "", ""
+	}
+	if over.Term != "" {
+		o.Term = over.Term
+	}
+	if over.Command != "" {
+		o.Command = over.Command
+	}
+	if over.Subsystem != "" {
+		o.Subsystem = over.Subsystem
+	}
+	if len(over.Modes) > 0 {
+		modes := make(map[string]uint32, len(o.Modes)+len(over.Modes))
+		for k, v := range o.Modes {
+			modes[k] = v
+		}
+		for k, v := range over.Modes {
+			modes[k] = v
+		}
+		o.Modes = modes
+	}
+	if len(over.Env) > 0 {
+		env := make(map[string]string, len(o.Env)+len(over.Env))
+		for k, v := range o.Env {
+			env[k] = v
+		}
+		for k, v := range over.Env {
+			env[k] = v
+		}
+		o.Env = env
+	}
+	return o
+}
+
+// terminalModes maps RFC 4254 mode names to opcodes.
+var terminalModes = map[string]uint8{
+	"VINTR": ssh.VINTR, "VQUIT": ssh.VQUIT, "VERASE": ssh.VERASE, "VKILL": ssh.VKILL,
+	"VEOF": ssh.VEOF, "VEOL": ssh.VEOL, "VEOL2": ssh.VEOL2, "VSTART": ssh.VSTART,
+	"VSTOP": ssh.VSTOP, "VSUSP": ssh.VSUSP, "VDSUSP": ssh.VDSUSP, "VREPRINT": ssh.VREPRINT,
+	"VWERASE": ssh.VWERASE, "VLNEXT": ssh.VLNEXT, "VFLUSH": ssh.VFLUSH, "VSWTCH": ssh.VSWTCH,
+	"VSTATUS": ssh.VSTATUS, "VDISCARD": ssh.VDISCARD,
+	"IGNPAR": ssh.IGNPAR, "PARMRK": ssh.PARMRK, "INPCK": ssh.INPCK, "ISTRIP": ssh.ISTRIP,
+	"INLCR": ssh.INLCR, "IGNCR": ssh.IGNCR, "ICRNL": ssh.ICRNL, "IUCLC": ssh.IUCLC,
+	"IXON": ssh.IXON, "IXANY": ssh.IXANY, "IXOFF": ssh.IXOFF, "IMAXBEL": ssh.IMAXBEL,
+	"IUTF8": ssh.IUTF8,
+	"ISIG":  ssh.ISIG, "ICANON": ssh.ICANON, "XCASE": ssh.XCASE, "ECHO": ssh.ECHO,
+	"ECHOE": ssh.ECHOE, "ECHOK": ssh.ECHOK, "ECHONL": ssh.ECHONL, "NOFLSH": ssh.NOFLSH,
+	"TOSTOP": ssh.TOSTOP, "IEXTEN": ssh.IEXTEN, "ECHOCTL": ssh.ECHOCTL, "ECHOKE": ssh.ECHOKE,
+	"PENDIN": ssh.PENDIN,
+	"OPOST":  ssh.OPOST, "OLCUC": ssh.OLCUC, "ONLCR": ssh.ONLCR, "OCRNL": ssh.OCRNL,
+	"ONOCR": ssh.ONOCR, "ONLRET": ssh.ONLRET,
+	"CS7": ssh.CS7, "CS8": ssh.CS8, "PARENB": ssh.PARENB, "PARODD": ssh.PARODD,
+	"TTY_OP_ISPEED": ssh.TTY_OP_ISPEED, "TTY_OP_OSPEED": ssh.TTY_OP_OSPEED,
+}
+
+// TerminalModeNames lists the mode names PtyOptions.Modes accepts.
+func TerminalModeNames() []string {
+	names := make([]string, 0, len(terminalModes))
+	for name := range terminalModes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// terminalModes returns the default modes with o.Modes applied.
+func (o PtyOptions) terminalModes() (ssh.TerminalModes, error) {
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	for name, v := range o.Modes {
+		op, ok := terminalModes[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown terminal mode %q", name)
+		}
+		modes[op] = v
+	}
+	return modes, nil
+}
+
+// term returns the TERM to request.
+func (o PtyOptions) term() string {
+	if t := strings.TrimSpace(o.Term); t != "" {
+		return t
+	}
+	return DefaultTerm
+}
+
+// setenv sends o.Env, sorted for a stable order. Rejected variables are
+// logged, not fatal, since most servers restrict AcceptEnv.
+func (o PtyOptions) setenv(sess *ssh.Session, host string) {
+	names := make([]string, 0, len(o.Env))
+	for name := range o.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := sess.Setenv(name, o.Env[name]); err != nil {
+			LogErrorf("Setenv %s rejected by %s: %v", name, host, err)
+		}
+	}
+}
+
+// start runs the configured program: a subsystem, a command or the shell.
+func (o PtyOptions) start(sess *ssh.Session) error {
+	switch {
+	case o.LoginShell:
+		return sess.Shell()
+	case o.Subsystem != "":
+		return sess.RequestSubsystem(o.Subsystem)
+	case strings.TrimSpace(o.Command) != "":
+		return sess.Start(o.Command)
+	default:
+		return sess.Shell()
+	}
+}
@@ -0,0 +1,267 @@
+package ssh
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// CastHeader is the first line of an asciicast v2 file.
+type CastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recorder writes terminal output and resizes to an asciicast v2 file.
+// It is safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	w       *bufio.Writer
+	start   time.Time
+	partial []byte // incomplete UTF-8 sequence held for the next chunk
+	err     error
+}
+
+// NewRecorder creates path (and its directory) and writes the cast header
+// for a rows x cols terminal of type term; empty term means DefaultTerm.
+func NewRecorder(path string, rows, cols int, title, term string) (*Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{path: path, f: f, w: bufio.NewWriter(f), start: time.Now()}
+	hdr, _ := json.Marshal(CastHeader{
+		Version:   2,
+		Width:     cols,
+		Height:    rows,
+		Timestamp: r.start.Unix(),
+		Title:     title,
+		Env:       map[string]string{"TERM": PtyOptions{Term: term}.term()},
+	})
+	r.w.Write(hdr)
+	r.w.WriteByte('\n')
+	if err := r.w.Flush(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	LogInfof("Recording to %s", path)
+	return r, nil
+}
+
+// Path returns the cast file being written.
+func (r *Recorder) Path() string {
+	if r == nil {
+		return ""
+	}
+	return r.path
+}
+
+// Output records a chunk of terminal output.
+func (r *Recorder) Output(p []byte) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data := append(r.partial, p...)
+	// Keep a trailing partial rune back so it is not mangled into U+FFFD.
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	r.partial = append([]byte(nil), data[cut:]...)
+	if cut > 0 {
+		r.eventLocked("o", string(data[:cut]))
+	}
+}
+
+// Resize records a terminal size change.
+func (r *Recorder) Resize(rows, cols int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.eventLocked("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+func (r *Recorder) eventLocked(code, data string) {
+	if r.f == nil || r.err != nil {
+		return
+	}
+	line, _ := json.Marshal([]interface{}{time.Since(r.start).Seconds(), code, data})
+	r.w.Write(line)
+	r.w.WriteByte('\n')
+	if err := r.w.Flush(); err != nil {
+		r.err = err
+		LogErrorf("Recording %s failed: %v", r.path, err)
+	}
+}
+
+// Close flushes and closes the file.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return r.err
+	}
+	if len(r.partial) > 0 {
+		r.eventLocked("o", string(r.partial))
+		r.partial = nil
+	}
+	err := r.w.Flush()
+	if cerr := r.f.Close(); err == nil {
+		err = cerr
+	}
+	r.f = nil
+	LogInfof("Recording %s closed", r.path)
+	if r.err != nil {
+		return r.err
+	}
+	return err
+}
+
+// recordingWriter tees stream output into the session's recorder.
+type recordingWriter struct {
+	st  *StreamSession
+	out io.Writer
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.st.mu.Lock()
+	rec := w.st.rec
+	w.st.mu.Unlock()
+	rec.Output(p)
+	return w.out.Write(p)
+}
+
+// SetRecorder attaches rec to the session, replacing and returning the
+// previous recorder. A nil rec stops recording; the caller closes the
+// returned recorder.
+func (st *StreamSession) SetRecorder(rec *Recorder) *Recorder {
+	if st == nil {
+		return rec
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	prev := st.rec
+	st.rec = rec
+	return prev
+}
+
+// Recorder returns the session's active recorder, if any.
+func (st *StreamSession) Recorder() *Recorder {
+	if st == nil {
+		return nil
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.rec
+}
+
+// PlayCast replays an asciicast v2 file, writing output events to out and
+// calling resize for "r" events, honouring the recorded timing. speed scales
+// playback (<= 0 means 1); idle pauses longer than maxIdle are shortened to
+// it when maxIdle > 0. It returns early when ctx is done.
+func PlayCast(ctx context.Context, path string, speed float64, maxIdle time.Duration, out io.Writer, resize func(rows, cols int)) (*CastHeader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if speed <= 0 {
+		speed = 1
+	}
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !sc.Scan() {
+		return nil, fmt.Errorf("empty cast file")
+	}
+	var hdr CastHeader
+	if err := json.Unmarshal(sc.Bytes(), &hdr); err != nil {
+		return nil, fmt.Errorf("invalid cast header: %v", err)
+	}
+	if hdr.Version != 2 {
+		return nil, fmt.Errorf("unsupported asciicast version %d", hdr.Version)
+	}
+	if resize != nil && hdr.Width > 0 && hdr.Height > 0 {
+		resize(hdr.Height, hdr.Width)
+	}
+
+	start := time.Now()
+	var last, shift float64 // shift accumulates idle time cut by maxIdle
+	for sc.Scan() {
+		var ev []json.RawMessage
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil || len(ev) != 3 {
+			continue
+		}
+		var at float64
+		var code, data string
+		if json.Unmarshal(ev[0], &at) != nil || json.Unmarshal(ev[1], &code) != nil || json.Unmarshal(ev[2], &data) != nil {
+			continue
+		}
+		if maxIdle > 0 && at-last > maxIdle.Seconds() {
+			shift += at - last - maxIdle.Seconds()
+		}
+		last = at
+		due := start.Add(time.Duration((at - shift) / speed * float64(time.Second)))
+		if d := time.Until(due); d > 0 {
+			t := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return &hdr, ctx.Err()
+			case <-t.C:
+			}
+		} else if ctx.Err() != nil {
+			return &hdr, ctx.Err()
+		}
+		switch code {
+		case "o":
+			if _, err := io.WriteString(out, data); err != nil {
+				return &hdr, err
+			}
+		case "r":
+			if resize == nil {
+				continue
+			}
+			cols, rows, ok := strings.Cut(data, "x")
+			if !ok {
+				continue
+			}
+			c, err1 := strconv.Atoi(cols)
+			r, err2 := strconv.Atoi(rows)
+			if err1 == nil && err2 == nil {
+				resize(r, c)
+			}
+		}
+	}
+	return &hdr, sc.Err()
+}
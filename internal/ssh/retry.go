@@ -0,0 +1,98 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Default reconnect policy used for zero-valued Retary fields.
+const (
+	DefaultRetryAttempts  = 5
+	DefaultRetryBaseDelay = time.Second
+	DefaultRetryMaxDelay  = 30 * time.Second
+	DefaultRetryJitter    = 0.2
+)
+
+// Retary is the reconnect policy applied when an established connection
+// drops. Zero fields take the Default* values.
+type Retary struct {
+	// Disabled turns automatic reconnect off.
+	Disabled    bool          `json:"disabled"`
+	MaxAttempts int           `json:"maxAttempts"`
+	BaseDelay   time.Duration `json:"baseDelay"`
+	MaxDelay    time.Duration `json:"maxDelay"`
+	// Jitter randomizes each delay by up to this fraction (0..1).
+	Jitter float64 `json:"jitter"`
+}
+
+// withDefaults fills zero fields with the package defaults.
+func (r Retary) withDefaults() Retary {
+	if r.MaxAttempts <= 0 {
+		r.MaxAttempts = DefaultRetryAttempts
+	}
+	if r.BaseDelay <= 0 {
+		r.BaseDelay = DefaultRetryBaseDelay
+	}
+	if r.MaxDelay <= 0 {
+		r.MaxDelay = DefaultRetryMaxDelay
+	}
+	if r.MaxDelay < r.BaseDelay {
+		r.MaxDelay = r.BaseDelay
+	}
+	if r.Jitter <= 0 || r.Jitter > 1 {
+		r.Jitter = DefaultRetryJitter
+	}
+	return r
+}
+
+// Delay returns the wait before attempt (1-based): BaseDelay doubled per
+// attempt, capped at MaxDelay and spread by ±Jitter.
+func (r Retary) Delay(attempt int) time.Duration {
+	r = r.withDefaults()
+	d := r.BaseDelay
+	for i := 1; i < attempt && d < r.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > r.MaxDelay {
+		d = r.MaxDelay
+	}
+	spread := float64(d) * r.Jitter
+	return time.Duration(float64(d) - spread + rand.Float64()*2*spread)
+}
+
+// ReconnectNotify is called before each reconnect attempt with the delay
+// about to be waited and the error of the previous attempt (nil at first).
+type ReconnectNotify func(attempt, max int, delay time.Duration, lastErr error)
+
+// Reconnect re-establishes the client of s following s.R, waiting with
+// exponential backoff between attempts. It stops early when ctx is done.
+func Reconnect(ctx context.Context, s *Sshobject, notify ReconnectNotify) error {
+	if s == nil {
+		return fmt.Errorf("nil ssh object")
+	}
+	policy := s.R.withDefaults()
+	if s.R.Disabled {
+		return fmt.Errorf("reconnect disabled")
+	}
+	var lastErr error
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		delay := policy.Delay(attempt)
+		if notify != nil {
+			notify(attempt, policy.MaxAttempts, delay, lastErr)
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		LogInfof("SSH reconnect attempt %d/%d to %s", attempt, policy.MaxAttempts, s.Host)
+		if lastErr = CreateClient(s); lastErr == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("reconnect to %s failed after %d attempts: %v", s.Host, policy.MaxAttempts, lastErr)
+}
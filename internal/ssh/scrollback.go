@@ -0,0 +1,475 @@
+package ssh
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Scrollback limits. The oldest lines are dropped once either the line count
+// or the bytes held (raw plus text) exceed the limit.
+const (
+	DefaultScrollbackLines = 10000
+	DefaultScrollbackBytes = 16 << 20
+	maxScrollbackLineLen   = 64 * 1024
+	maxTranscriptMatches   = 1000
+)
+
+// ScrollbackLine is one line of terminal output. N is its absolute line
+// number, stable while the line stays in the buffer. Raw keeps the escape
+// sequences for replaying into a terminal; Text is the plain text.
+type ScrollbackLine struct {
+	N    int    `json:"n"`
+	Text string `json:"text"`
+	Raw  string `json:"raw"`
+}
+
+// ScrollbackPage is a window of the buffer. First is the oldest line number
+// still held and Total the number of lines ever written.
+type ScrollbackPage struct {
+	Lines []ScrollbackLine `json:"lines"`
+	First int              `json:"first"`
+	Total int              `json:"total"`
+}
+
+// TranscriptMatch is a search hit; Start and End are byte offsets of the
+// first match within Text.
+type TranscriptMatch struct {
+	N     int    `json:"n"`
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// ansi parser states
+const (
+	ansiText = iota
+	ansiEsc
+	ansiCSI
+	ansiOSC
+	ansiOSCEsc
+	ansiString // DCS, SOS, PM, APC: skipped until ST
+	ansiStringEsc
+	ansiCharset
+)
+
+// Scrollback is a bounded ring of terminal output lines with an ANSI
+// stripped copy for searching. It is safe for concurrent use.
+type Scrollback struct {
+	mu       sync.Mutex
+	max      int
+	maxBytes int
+	lines    []ScrollbackLine // ring of count lines, oldest at head
+	head     int
+	count    int
+	bytes    int
+	total    int
+
+	raw   []byte
+	text  []byte
+	state int
+}
+
+// NewScrollback returns a buffer holding up to maxLines lines
+// (DefaultScrollbackLines when <= 0).
+func NewScrollback(maxLines int) *Scrollback {
+	if maxLines <= 0 {
+		maxLines = DefaultScrollbackLines
+	}
+	return &Scrollback{max: maxLines, maxBytes: DefaultScrollbackBytes}
+}
+
+// Write appends terminal output. It never fails.
+func (sb *Scrollback) Write(p []byte) (int, error) {
+	if sb == nil {
+		return len(p), nil
+	}
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	for _, c := range p {
+		if c == '\n' {
+			sb.pushLocked()
+			continue
+		}
+		if len(sb.raw) >= maxScrollbackLineLen {
+			sb.pushLocked()
+		}
+		sb.raw = append(sb.raw, c)
+		sb.stripLocked(c)
+	}
+	return len(p), nil
+}
+
+// stripLocked feeds one byte through the escape sequence parser, keeping
+// printable text.
+func (sb *Scrollback) stripLocked(c byte) {
+	switch sb.state {
+	case ansiText:
+		switch {
+		case c == 0x1b:
+			sb.state = ansiEsc
+		case c == '\r':
+			// Carriage return redraws the line; keep what comes after.
+			sb.text = sb.text[:0]
+		case c == '\b':
+			if len(sb.text) > 0 {
+				_, n := utf8.DecodeLastRune(sb.text)
+				sb.text = sb.text[:len(sb.text)-n]
+			}
+		case c == '\t' || c >= 0x20 && c != 0x7f:
+			sb.text = append(sb.text, c)
+		}
+	case ansiEsc:
+		switch c {
+		case '[':
+			sb.state = ansiCSI
+		case ']':
+			sb.state = ansiOSC
+		case 'P', 'X', '^', '_':
+			sb.state = ansiString
+		case '(', ')', '*', '+', '#', '%':
+			sb.state = ansiCharset
+		default:
+			sb.state = ansiText
+		}
+	case ansiCSI:
+		if c >= 0x40 && c <= 0x7e {
+			sb.state = ansiText
+		}
+	case ansiOSC:
+		switch c {
+		case 0x07:
+			sb.state = ansiText
+		case 0x1b:
+			sb.state = ansiOSCEsc
+		}
+	case ansiOSCEsc:
+		sb.state = ansiText
+		if c != '\\' {
+			sb.state = ansiOSC
+		}
+	case ansiString:
+		if c == 0x1b {
+			sb.state = ansiStringEsc
+		}
+	case ansiStringEsc:
+		sb.state = ansiString
+		if c == '\\' {
+			sb.state = ansiText
+		}
+	case ansiCharset:
+		sb.state = ansiText
+	}
+}
+
+func (sb *Scrollback) pushLocked() {
+	raw := strings.TrimSuffix(string(sb.raw), "\r")
+	line := ScrollbackLine{N: sb.total, Text: string(sb.text), Raw: raw}
+	size := lineSize(line)
+	for sb.count > 0 && (sb.count >= sb.max || sb.bytes+size > sb.maxBytes) {
+		sb.evictLocked()
+	}
+	switch {
+	case sb.count < len(sb.lines):
+		sb.lines[(sb.head+sb.count)%len(sb.lines)] = line
+	case sb.head == 0:
+		sb.lines = append(sb.lines, line)
+	default:
+		// Full ring that is still shorter than max: unroll it so it can grow.
+		sb.lines = append(sb.snapshotRingLocked(), line)
+		sb.head = 0
+	}
+	sb.count++
+	sb.bytes += size
+	sb.total++
+	sb.raw, sb.text = sb.raw[:0], sb.text[:0]
+}
+
+// evictLocked drops the oldest line.
+func (sb *Scrollback) evictLocked() {
+	sb.bytes -= lineSize(sb.lines[sb.head])
+	sb.lines[sb.head] = ScrollbackLine{}
+	sb.head = (sb.head + 1) % len(sb.lines)
+	sb.count--
+}
+
+func lineSize(l ScrollbackLine) int { return len(l.Raw) + len(l.Text) }
+
+// snapshotRingLocked returns the held lines oldest first.
+func (sb *Scrollback) snapshotRingLocked() []ScrollbackLine {
+	out := make([]ScrollbackLine, 0, sb.count+1)
+	for i := 0; i < sb.count; i++ {
+		out = append(out, sb.lines[(sb.head+i)%len(sb.lines)])
+	}
+	return out
+}
+
+// snapshotLocked returns the held lines oldest first, including the
+// unterminated current line.
+func (sb *Scrollback) snapshotLocked() []ScrollbackLine {
+	out := sb.snapshotRingLocked()
+	if len(sb.raw) > 0 {
+		out = append(out, ScrollbackLine{N: sb.total, Text: string(sb.text), Raw: string(sb.raw)})
+	}
+	return out
+}
+
+// Page returns up to limit lines starting at absolute line offset. A
+// negative offset counts back from the end, so (-100, 100) is the last
+// hundred lines. limit <= 0 returns everything from offset.
+func (sb *Scrollback) Page(offset, limit int) ScrollbackPage {
+	if sb == nil {
+		return ScrollbackPage{}
+	}
+	sb.mu.Lock()
+	lines := sb.snapshotLocked()
+	total := sb.total
+	first := total - sb.count
+	sb.mu.Unlock()
+
+	page := ScrollbackPage{First: first, Total: total}
+	if len(lines) == 0 {
+		return page
+	}
+	end := lines[len(lines)-1].N + 1
+	if offset < 0 {
+		offset += end
+	}
+	if offset < first {
+		offset = first
+	}
+	if offset >= end {
+		return page
+	}
+	lines = lines[offset-first:]
+	if limit > 0 && len(lines) > limit {
+		lines = lines[:limit]
+	}
+	page.Lines = lines
+	return page
+}
+
+// Search returns the lines whose text matches pattern, oldest first.
+func (sb *Scrollback) Search(pattern string) ([]TranscriptMatch, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+	if sb == nil {
+		return nil, nil
+	}
+	sb.mu.Lock()
+	lines := sb.snapshotLocked()
+	sb.mu.Unlock()
+
+	var matches []TranscriptMatch
+	for _, l := range lines {
+		loc := re.FindStringIndex(l.Text)
+		if loc == nil {
+			continue
+		}
+		matches = append(matches, TranscriptMatch{N: l.N, Text: l.Text, Start: loc[0], End: loc[1]})
+		if len(matches) >= maxTranscriptMatches {
+			break
+		}
+	}
+	return matches, nil
+}
+
+// Text returns the whole buffer as plain text.
+func (sb *Scrollback) Text() string {
+	if sb == nil {
+		return ""
+	}
+	sb.mu.Lock()
+	lines := sb.snapshotLocked()
+	sb.mu.Unlock()
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(l.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// HTML returns the whole buffer as a standalone HTML page, keeping SGR
+// colours and bold/italic/underline.
+func (sb *Scrollback) HTML(title string) string {
+	var lines []ScrollbackLine
+	if sb != nil {
+		sb.mu.Lock()
+		lines = sb.snapshotLocked()
+		sb.mu.Unlock()
+	}
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>")
+	b.WriteString(html.EscapeString(title))
+	b.WriteString("</title></head>\n<body style=\"background:#1b2636;color:#d0d0d0\">\n<pre style=\"font-family:monospace\">")
+	var st sgrState
+	for _, l := range lines {
+		st.render(&b, l.Raw)
+		b.WriteByte('\n')
+	}
+	st.close(&b)
+	b.WriteString("</pre>\n</body></html>\n")
+	return b.String()
+}
+
+// sgrState tracks the text attributes while rendering raw output as HTML.
+type sgrState struct {
+	fg, bg                  string
+	bold, italic, underline bool
+	open                    bool
+}
+
+// ansiPalette is the xterm 16 colour palette.
+var ansiPalette = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+var csiRe = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[P^_X][^\x1b]*\x1b\\|\x1b[()*+#%].|\x1b.`)
+
+func (st *sgrState) render(b *strings.Builder, raw string) {
+	last := 0
+	for _, loc := range csiRe.FindAllStringIndex(raw, -1) {
+		st.text(b, raw[last:loc[0]])
+		seq := raw[loc[0]:loc[1]]
+		if strings.HasPrefix(seq, "\x1b[") && strings.HasSuffix(seq, "m") {
+			st.apply(b, seq[2:len(seq)-1])
+		}
+		last = loc[1]
+	}
+	st.text(b, raw[last:])
+}
+
+func (st *sgrState) text(b *strings.Builder, s string) {
+	if i := strings.LastIndexByte(s, '\r'); i >= 0 {
+		s = s[i+1:]
+	}
+	s = strings.Map(func(r rune) rune {
+		if r == '\t' || r >= 0x20 && r != 0x7f {
+			return r
+		}
+		return -1
+	}, s)
+	if s == "" {
+		return
+	}
+	b.WriteString(html.EscapeString(s))
+}
+
+func (st *sgrState) apply(b *strings.Builder, params string) {
+	if params == "" {
+		params = "0"
+	}
+	ps := strings.Split(params, ";")
+	for i := 0; i < len(ps); i++ {
+		n, _ := strconv.Atoi(ps[i])
+		switch {
+		case n == 0:
+			*st = sgrState{open: st.open}
+		case n == 1:
+			st.bold = true
+		case n == 3:
+			st.italic = true
+		case n == 4:
+			st.underline = true
+		case n == 22:
+			st.bold = false
+		case n == 23:
+			st.italic = false
+		case n == 24:
+			st.underline = false
+		case n >= 30 && n <= 37:
+			st.fg = ansiPalette[n-30]
+		case n >= 90 && n <= 97:
+			st.fg = ansiPalette[n-90+8]
+		case n == 39:
+			st.fg = ""
+		case n >= 40 && n <= 47:
+			st.bg = ansiPalette[n-40]
+		case n >= 100 && n <= 107:
+			st.bg = ansiPalette[n-100+8]
+		case n == 49:
+			st.bg = ""
+		case n == 38 || n == 48:
+			c, used := extendedColor(ps[i+1:])
+			i += used
+			if n == 38 {
+				st.fg = c
+			} else {
+				st.bg = c
+			}
+		}
+	}
+	st.close(b)
+	var css []string
+	if st.fg != "" {
+		css = append(css, "color:"+st.fg)
+	}
+	if st.bg != "" {
+		css = append(css, "background:"+st.bg)
+	}
+	if st.bold {
+		css = append(css, "font-weight:bold")
+	}
+	if st.italic {
+		css = append(css, "font-style:italic")
+	}
+	if st.underline {
+		css = append(css, "text-decoration:underline")
+	}
+	if len(css) > 0 {
+		b.WriteString(`<span style="` + strings.Join(css, ";") + `">`)
+		st.open = true
+	}
+}
+
+func (st *sgrState) close(b *strings.Builder) {
+	if st.open {
+		b.WriteString("</span>")
+		st.open = false
+	}
+}
+
+// extendedColor decodes the arguments after 38/48: "5;n" or "2;r;g;b". It
+// returns the CSS colour and how many parameters it consumed.
+func extendedColor(ps []string) (string, int) {
+	if len(ps) >= 2 && ps[0] == "5" {
+		n, _ := strconv.Atoi(ps[1])
+		return color256(n), 2
+	}
+	if len(ps) >= 4 && ps[0] == "2" {
+		r, _ := strconv.Atoi(ps[1])
+		g, _ := strconv.Atoi(ps[2])
+		bl, _ := strconv.Atoi(ps[3])
+		return fmt.Sprintf("#%02x%02x%02x", r&0xff, g&0xff, bl&0xff), 4
+	}
+	return "", len(ps)
+}
+
+func color256(n int) string {
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return ansiPalette[n]
+	case n < 232:
+		n -= 16
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6))
+	default:
+		v := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", v, v, v)
+	}
+}
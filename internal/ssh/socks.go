@@ -0,0 +1,559 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SOCKS5 protocol constants (RFC 1928, RFC 1929).
+const (
+	socksVersion = 0x05
+
+	socksMethodNone         = 0x00
+	socksMethodPassword     = 0x02
+	socksMethodNoAcceptable = 0xff
+
+	socksCmdConnect = 0x01
+	socksCmdBind    = 0x02
+	socksCmdUDP     = 0x03
+
+	socksAtypIPv4   = 0x01
+	socksAtypDomain = 0x03
+	socksAtypIPv6   = 0x04
+
+	socksSucceeded         = 0x00
+	socksGeneralFailure    = 0x01
+	socksNotAllowed        = 0x02
+	socksHostUnreachable   = 0x04
+	socksConnRefused       = 0x05
+	socksCmdNotSupported   = 0x07
+	socksAddrNotSupported  = 0x08
+	socksAuthVersion       = 0x01
+	socksBindAcceptTimeout = 2 * time.Minute
+	socksUDPHelperTimeout  = 10 * time.Second
+	socksMaxDatagram       = 64 * 1024
+	socksDNSPort           = 53
+	socksHandshakeTimeout  = 30 * time.Second
+)
+
+// SocksOptions configures a dynamic (SOCKS5) forward.
+type SocksOptions struct {
+	// Username and Password require RFC 1929 authentication when Username
+	// is set; otherwise clients must offer "no authentication".
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// UDPHelper is a remote command that relays one UDP datagram for UDP
+	// ASSOCIATE: it reads the datagram on stdin and prints the reply.
+	// {host} and {port} are replaced with the destination, e.g.
+	// "socat -T2 - UDP:{host}:{port}". Without it only DNS (port 53) is
+	// relayed, as DNS over TCP.
+	UDPHelper string `json:"udpHelper,omitempty"`
+}
+
+// socksAddr is a SOCKS address: an IP or a domain name, and a port.
+type socksAddr struct {
+	host string
+	ip   net.IP
+	port int
+}
+
+func (a socksAddr) String() string {
+	host := a.host
+	if a.ip != nil {
+		host = a.ip.String()
+	}
+	return net.JoinHostPort(host, strconv.Itoa(a.port))
+}
+
+// readSocksAddr reads ATYP DST.ADDR DST.PORT.
+func readSocksAddr(r io.Reader) (socksAddr, byte, error) {
+	var a socksAddr
+	atyp := make([]byte, 1)
+	if _, err := io.ReadFull(r, atyp); err != nil {
+		return a, 0, err
+	}
+	switch atyp[0] {
+	case socksAtypIPv4, socksAtypIPv6:
+		n := net.IPv4len
+		if atyp[0] == socksAtypIPv6 {
+			n = net.IPv6len
+		}
+		ip := make([]byte, n)
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return a, 0, err
+		}
+		a.ip = net.IP(ip)
+	case socksAtypDomain:
+		l := make([]byte, 1)
+		if _, err := io.ReadFull(r, l); err != nil {
+			return a, 0, err
+		}
+		d := make([]byte, int(l[0]))
+		if _, err := io.ReadFull(r, d); err != nil {
+			return a, 0, err
+		}
+		a.host = string(d)
+	default:
+		return a, socksAddrNotSupported, fmt.Errorf("unsupported address type %#x", atyp[0])
+	}
+	p := make([]byte, 2)
+	if _, err := io.ReadFull(r, p); err != nil {
+		return a, 0, err
+	}
+	a.port = int(binary.BigEndian.Uint16(p))
+	return a, 0, nil
+}
+
+// appendSocksAddr encodes addr as ATYP BND.ADDR BND.PORT. Addresses that are
+// not IPs are sent as domain names; a nil addr is 0.0.0.0:0.
+func appendSocksAddr(b []byte, addr net.Addr) []byte {
+	var ip net.IP
+	port := 0
+	host := ""
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip, port = a.IP, a.Port
+	case *net.UDPAddr:
+		ip, port = a.IP, a.Port
+	case nil:
+	default:
+		h, p, err := net.SplitHostPort(a.String())
+		if err == nil {
+			port, _ = strconv.Atoi(p)
+			if ip = net.ParseIP(h); ip == nil {
+				host = h
+			}
+		}
+	}
+	switch {
+	case host != "" && len(host) <= 255:
+		b = append(b, socksAtypDomain, byte(len(host)))
+		b = append(b, host...)
+	case ip.To4() != nil:
+		b = append(b, socksAtypIPv4)
+		b = append(b, ip.To4()...)
+	case ip != nil:
+		b = append(b, socksAtypIPv6)
+		b = append(b, ip.To16()...)
+	default:
+		b = append(b, socksAtypIPv4, 0, 0, 0, 0)
+	}
+	return binary.BigEndian.AppendUint16(b, uint16(port))
+}
+
+func writeSocksReply(w io.Writer, rep byte, bnd net.Addr) error {
+	_, err := w.Write(appendSocksAddr([]byte{socksVersion, rep, 0x00}, bnd))
+	return err
+}
+
+// socksReplyCode maps a dial error to a SOCKS reply.
+func socksReplyCode(err error) byte {
+	var oce *ssh.OpenChannelError
+	if errors.As(err, &oce) {
+		switch oce.Reason {
+		case ssh.Prohibited:
+			return socksNotAllowed
+		case ssh.ConnectionFailed:
+			msg := strings.ToLower(oce.Message)
+			if strings.Contains(msg, "unreachable") || strings.Contains(msg, "no route") {
+				return socksHostUnreachable
+			}
+			return socksConnRefused
+		}
+	}
+	return socksGeneralFailure
+}
+
+// socksNegotiate performs method selection and, when configured, RFC 1929
+// username/password authentication. br has already consumed the version.
+func socksNegotiate(c net.Conn, br *bufio.Reader, opts SocksOptions) error {
+	n, err := br.ReadByte()
+	if err != nil {
+		return err
+	}
+	methods := make([]byte, int(n))
+	if _, err := io.ReadFull(br, methods); err != nil {
+		return err
+	}
+	want := byte(socksMethodNone)
+	if opts.Username != "" {
+		want = socksMethodPassword
+	}
+	if bytes.IndexByte(methods, want) < 0 {
+		_, _ = c.Write([]byte{socksVersion, socksMethodNoAcceptable})
+		return fmt.Errorf("no acceptable authentication method offered")
+	}
+	if _, err := c.Write([]byte{socksVersion, want}); err != nil {
+		return err
+	}
+	if want == socksMethodNone {
+		return nil
+	}
+
+	// RFC 1929: VER ULEN UNAME PLEN PASSWD
+	ver, err := br.ReadByte()
+	if err != nil {
+		return err
+	}
+	if ver != socksAuthVersion {
+		return fmt.Errorf("bad auth version %#x", ver)
+	}
+	readField := func() ([]byte, error) {
+		l, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		f := make([]byte, int(l))
+		_, err = io.ReadFull(br, f)
+		return f, err
+	}
+	user, err := readField()
+	if err != nil {
+		return err
+	}
+	pass, err := readField()
+	if err != nil {
+		return err
+	}
+	okUser := subtle.ConstantTimeCompare(user, []byte(opts.Username)) == 1
+	okPass := subtle.ConstantTimeCompare(pass, []byte(opts.Password)) == 1
+	if !okUser || !okPass {
+		_, _ = c.Write([]byte{socksAuthVersion, 0x01})
+		return fmt.Errorf("authentication failed for user %q", user)
+	}
+	_, err = c.Write([]byte{socksAuthVersion, 0x00})
+	return err
+}
+
+// serveSocks5 handles one SOCKS5 client; br has already consumed the
+// version byte.
+func (s *Sshobject) serveSocks5(c net.Conn, br *bufio.Reader, tracker *forwardTracker, opts SocksOptions) {
+	_ = c.SetDeadline(time.Now().Add(socksHandshakeTimeout))
+	if err := socksNegotiate(c, br, opts); err != nil {
+		LogErrorf("SOCKS %s: %v", c.RemoteAddr(), err)
+		return
+	}
+
+	// request: VER CMD RSV ATYP DST.ADDR DST.PORT
+	hdr := make([]byte, 3)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return
+	}
+	if hdr[0] != socksVersion {
+		return
+	}
+	dst, rep, err := readSocksAddr(br)
+	if err != nil {
+		if rep != 0 {
+			_ = writeSocksReply(c, rep, nil)
+		}
+		return
+	}
+	_ = c.SetDeadline(time.Time{})
+
+	switch hdr[1] {
+	case socksCmdConnect:
+		s.socksConnect(c, br, tracker, dst)
+	case socksCmdBind:
+		s.socksBind(c, br, tracker, dst)
+	case socksCmdUDP:
+		s.socksUDPAssociate(c, br, dst, opts)
+	default:
+		_ = writeSocksReply(c, socksCmdNotSupported, nil)
+	}
+}
+
+func (s *Sshobject) socksConnect(c net.Conn, br *bufio.Reader, tracker *forwardTracker, dst socksAddr) {
+	rc, err := s.client.Dial("tcp", dst.String())
+	if err != nil {
+		LogErrorf("SOCKS dial %s via SSH failed: %v", dst, err)
+		tracker.fail(err)
+		_ = writeSocksReply(c, socksReplyCode(err), nil)
+		return
+	}
+	if tracker != nil && !tracker.trackConn(rc) {
+		rc.Close()
+		return
+	}
+	defer func() {
+		if tracker != nil {
+			tracker.untrackConn(rc)
+		}
+		rc.Close()
+	}()
+	if err := writeSocksReply(c, socksSucceeded, rc.LocalAddr()); err != nil {
+		return
+	}
+	proxyPipe(bufferedConn{c, br}, rc)
+}
+
+// socksBind listens on the server side of the tunnel for one inbound
+// connection, as FTP active mode needs. The first reply carries the address
+// the peer should connect to, the second the peer's address.
+func (s *Sshobject) socksBind(c net.Conn, br *bufio.Reader, tracker *forwardTracker, dst socksAddr) {
+	ln, err := s.client.Listen("tcp", "0.0.0.0:0")
+	if err != nil {
+		LogErrorf("SOCKS bind via SSH failed: %v", err)
+		tracker.fail(err)
+		_ = writeSocksReply(c, socksReplyCode(err), nil)
+		return
+	}
+	defer ln.Close()
+
+	bnd := ln.Addr()
+	if a, ok := bnd.(*net.TCPAddr); ok && (a.IP == nil || a.IP.IsUnspecified()) {
+		// The server listens on all interfaces; advertise the address we
+		// reached it at.
+		if ra, ok := s.client.RemoteAddr().(*net.TCPAddr); ok {
+			bnd = &net.TCPAddr{IP: ra.IP, Port: a.Port}
+		}
+	}
+	if err := writeSocksReply(c, socksSucceeded, bnd); err != nil {
+		return
+	}
+
+	type accepted struct {
+		conn net.Conn
+		err  error
+	}
+	ch := make(chan accepted, 1)
+	go func() {
+		rc, err := ln.Accept()
+		ch <- accepted{rc, err}
+	}()
+	var rc net.Conn
+	select {
+	case a := <-ch:
+		if a.err != nil {
+			_ = writeSocksReply(c, socksGeneralFailure, nil)
+			return
+		}
+		rc = a.conn
+	case <-time.After(socksBindAcceptTimeout):
+		_ = writeSocksReply(c, socksGeneralFailure, nil)
+		return
+	}
+	if dst.ip != nil && !dst.ip.IsUnspecified() {
+		if ra, ok := rc.RemoteAddr().(*net.TCPAddr); ok && !ra.IP.Equal(dst.ip) {
+			LogErrorf("SOCKS bind: unexpected peer %s (want %s)", ra, dst.ip)
+			rc.Close()
+			_ = writeSocksReply(c, socksNotAllowed, nil)
+			return
+		}
+	}
+	if tracker != nil && !tracker.trackConn(rc) {
+		rc.Close()
+		return
+	}
+	defer func() {
+		if tracker != nil {
+			tracker.untrackConn(rc)
+		}
+		rc.Close()
+	}()
+	if err := writeSocksReply(c, socksSucceeded, rc.RemoteAddr()); err != nil {
+		return
+	}
+	proxyPipe(bufferedConn{c, br}, rc)
+}
+
+// socksUDPAssociate relays datagrams from the client for as long as its
+// control connection stays open. SSH has no UDP channel, so DNS queries are
+// sent as DNS over TCP and other datagrams go through opts.UDPHelper.
+func (s *Sshobject) socksUDPAssociate(c net.Conn, br *bufio.Reader, dst socksAddr, opts SocksOptions) {
+	clientIP := net.IP(nil)
+	if ra, ok := c.RemoteAddr().(*net.TCPAddr); ok {
+		clientIP = ra.IP
+	}
+	bindIP := net.IPv4zero
+	if la, ok := c.LocalAddr().(*net.TCPAddr); ok {
+		bindIP = la.IP
+	}
+	pc, err := net.ListenUDP("udp", &net.UDPAddr{IP: bindIP})
+	if err != nil {
+		_ = writeSocksReply(c, socksGeneralFailure, nil)
+		return
+	}
+	defer pc.Close()
+	if err := writeSocksReply(c, socksSucceeded, pc.LocalAddr()); err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		// The association ends with the TCP connection.
+		_, _ = io.Copy(io.Discard, br)
+		cancel()
+		_ = pc.Close()
+	}()
+
+	buf := make([]byte, socksMaxDatagram)
+	for {
+		n, from, err := pc.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if clientIP != nil && !from.IP.Equal(clientIP) {
+			continue
+		}
+		if dst.port != 0 && from.Port != dst.port {
+			continue
+		}
+		target, payload, err := parseSocksUDP(buf[:n])
+		if err != nil {
+			continue
+		}
+		go func(from *net.UDPAddr) {
+			reply, err := s.relayDatagram(ctx, target, payload, opts)
+			if err != nil {
+				LogErrorf("SOCKS UDP relay to %s failed: %v", target, err)
+				return
+			}
+			src := &net.UDPAddr{IP: target.ip, Port: target.port}
+			var out []byte
+			if target.ip == nil {
+				out = appendSocksAddr([]byte{0, 0, 0}, hostPortAddr(target.String()))
+			} else {
+				out = appendSocksAddr([]byte{0, 0, 0}, src)
+			}
+			_, _ = pc.WriteToUDP(append(out, reply...), from)
+		}(from)
+	}
+}
+
+// parseSocksUDP splits a UDP ASSOCIATE datagram,
+// RSV(2) FRAG(1) ATYP DST.ADDR DST.PORT DATA, into its target and a copy of
+// the payload. Fragments are rejected: reassembly is optional and rare.
+func parseSocksUDP(pkt []byte) (socksAddr, []byte, error) {
+	if len(pkt) < 4 {
+		return socksAddr{}, nil, fmt.Errorf("short datagram")
+	}
+	if pkt[2] != 0 {
+		return socksAddr{}, nil, fmt.Errorf("fragmented datagram dropped")
+	}
+	r := bytes.NewReader(pkt[3:])
+	target, _, err := readSocksAddr(r)
+	if err != nil {
+		return socksAddr{}, nil, err
+	}
+	payload := make([]byte, r.Len())
+	_, _ = r.Read(payload)
+	return target, payload, nil
+}
+
+// relayDatagram sends one datagram to target through the tunnel and returns
+// the reply.
+func (s *Sshobject) relayDatagram(ctx context.Context, target socksAddr, payload []byte, opts SocksOptions) ([]byte, error) {
+	if helper := strings.TrimSpace(opts.UDPHelper); helper != "" {
+		host := target.host
+		if target.ip != nil {
+			host = target.ip.String()
+		}
+		cmd := strings.NewReplacer("{host}", shellQuote(host), "{port}", strconv.Itoa(target.port)).Replace(helper)
+		var out bytes.Buffer
+		sess, err := s.client.NewSession()
+		if err != nil {
+			return nil, err
+		}
+		defer sess.Close()
+		sess.Stdin = bytes.NewReader(payload)
+		sess.Stdout = &limitedWriter{w: &out, n: socksMaxDatagram}
+		done := make(chan error, 1)
+		go func() { done <- sess.Run(cmd) }()
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(socksUDPHelperTimeout):
+			return nil, fmt.Errorf("udp helper timed out")
+		}
+		if err != nil && out.Len() == 0 {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	}
+	if target.port != socksDNSPort {
+		return nil, fmt.Errorf("udp to port %d needs a udp helper", target.port)
+	}
+
+	// DNS over TCP (RFC 1035 4.2.2): each message is prefixed with its length.
+	rc, err := s.client.Dial("tcp", target.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	stop := context.AfterFunc(ctx, func() { _ = rc.Close() })
+	defer stop()
+	msg := binary.BigEndian.AppendUint16(nil, uint16(len(payload)))
+	if _, err := rc.Write(append(msg, payload...)); err != nil {
+		return nil, err
+	}
+	l := make([]byte, 2)
+	if _, err := io.ReadFull(rc, l); err != nil {
+		return nil, err
+	}
+	reply := make([]byte, int(binary.BigEndian.Uint16(l)))
+	if _, err := io.ReadFull(rc, reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// hostPortAddr is a net.Addr for a host:port whose host is a name.
+type hostPortAddr string
+
+func (a hostPortAddr) Network() string { return "udp" }
+func (a hostPortAddr) String() string  { return string(a) }
+
+// limitedWriter drops writes past n bytes.
+type limitedWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.n <= 0 {
+		return len(p), nil
+	}
+	q := p
+	if len(q) > l.n {
+		q = q[:l.n]
+	}
+	l.n -= len(q)
+	_, err := l.w.Write(q)
+	return len(p), err
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// bufferedConn reads through a bufio.Reader that may hold bytes already
+// received from the connection.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (b bufferedConn) Read(p []byte) (int, error) { return b.r.Read(p) }
+
+func (b bufferedConn) CloseWrite() error {
+	if cw, ok := b.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return b.Conn.Close()
+}
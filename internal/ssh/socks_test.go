@@ -0,0 +1,184 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// socksPipe runs serve on the server end of a pipe and returns the client
+// end. serve receives a reader that has already consumed the version byte,
+// as handleSocks5 leaves it.
+func socksPipe(t *testing.T, serve func(c net.Conn, br *bufio.Reader)) (net.Conn, <-chan struct{}) {
+	t.Helper()
+	client, server := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer server.Close()
+		br := bufio.NewReader(server)
+		if v, err := br.ReadByte(); err != nil || v != socksVersion {
+			return
+		}
+		serve(server, br)
+	}()
+	t.Cleanup(func() { client.Close() })
+	_ = client.SetDeadline(time.Now().Add(5 * time.Second))
+	return client, done
+}
+
+func send(t *testing.T, c net.Conn, b ...byte) {
+	t.Helper()
+	if _, err := c.Write(b); err != nil {
+		t.Fatalf("write %x: %v", b, err)
+	}
+}
+
+func expect(t *testing.T, c net.Conn, want ...byte) {
+	t.Helper()
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(c, got); err != nil {
+		t.Fatalf("read, want %x: %v", want, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %x, want %x", got, want)
+	}
+}
+
+func negotiate(opts SocksOptions) func(net.Conn, *bufio.Reader) {
+	return func(c net.Conn, br *bufio.Reader) { _ = socksNegotiate(c, br, opts) }
+}
+
+func TestSocksNegotiateNoAuth(t *testing.T) {
+	c, _ := socksPipe(t, negotiate(SocksOptions{}))
+	send(t, c, 5, 2, 0x02, 0x00)
+	expect(t, c, 5, 0x00)
+}
+
+func TestSocksNegotiatePassword(t *testing.T) {
+	opts := SocksOptions{Username: "alice", Password: "s3cret"}
+
+	c, done := socksPipe(t, negotiate(opts))
+	send(t, c, 5, 1, 0x02)
+	expect(t, c, 5, 0x02)
+	send(t, c, 1, 5, 'a', 'l', 'i', 'c', 'e', 6, 's', '3', 'c', 'r', 'e', 't')
+	expect(t, c, 1, 0x00)
+	<-done
+
+	c, done = socksPipe(t, negotiate(opts))
+	send(t, c, 5, 1, 0x02)
+	expect(t, c, 5, 0x02)
+	send(t, c, 1, 5, 'a', 'l', 'i', 'c', 'e', 5, 'w', 'r', 'o', 'n', 'g')
+	expect(t, c, 1, 0x01)
+	<-done
+}
+
+func TestSocksNegotiateNoAcceptableMethod(t *testing.T) {
+	// Password auth is required but the client only offers "none".
+	c, done := socksPipe(t, negotiate(SocksOptions{Username: "alice", Password: "x"}))
+	send(t, c, 5, 1, 0x00)
+	expect(t, c, 5, socksMethodNoAcceptable)
+	<-done
+
+	// No auth configured but the client only offers GSSAPI.
+	c, done = socksPipe(t, negotiate(SocksOptions{}))
+	send(t, c, 5, 1, 0x01)
+	expect(t, c, 5, socksMethodNoAcceptable)
+	<-done
+}
+
+func TestServeSocks5Errors(t *testing.T) {
+	s := &Sshobject{}
+	serve := func(c net.Conn, br *bufio.Reader) { s.serveSocks5(c, br, nil, SocksOptions{}) }
+	zeroBnd := []byte{socksAtypIPv4, 0, 0, 0, 0, 0, 0}
+
+	t.Run("unsupported atyp", func(t *testing.T) {
+		c, done := socksPipe(t, serve)
+		send(t, c, 5, 1, 0x00)
+		expect(t, c, 5, 0x00)
+		send(t, c, 5, socksCmdConnect, 0, 0x09)
+		expect(t, c, append([]byte{5, socksAddrNotSupported, 0}, zeroBnd...)...)
+		<-done
+	})
+
+	t.Run("unsupported cmd", func(t *testing.T) {
+		c, done := socksPipe(t, serve)
+		send(t, c, 5, 1, 0x00)
+		expect(t, c, 5, 0x00)
+		send(t, c, 5, 0x09, 0, socksAtypIPv4, 10, 0, 0, 1, 0, 80)
+		expect(t, c, append([]byte{5, socksCmdNotSupported, 0}, zeroBnd...)...)
+		<-done
+	})
+}
+
+func TestAppendSocksAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		addr net.Addr
+		want []byte
+	}{
+		{"ipv4", &net.TCPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 1080},
+			[]byte{socksAtypIPv4, 192, 168, 1, 2, 0x04, 0x38}},
+		{"ipv6", &net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 53},
+			[]byte{socksAtypIPv6, 0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 53}},
+		{"domain", hostPortAddr("example.com:443"),
+			append(append([]byte{socksAtypDomain, 11}, "example.com"...), 0x01, 0xbb)},
+		{"ip string", hostPortAddr("10.0.0.1:22"),
+			[]byte{socksAtypIPv4, 10, 0, 0, 1, 0, 22}},
+		{"nil", nil, []byte{socksAtypIPv4, 0, 0, 0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		if got := appendSocksAddr(nil, tt.addr); !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: got %x, want %x", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadSocksAddrRoundTrip(t *testing.T) {
+	for _, addr := range []net.Addr{
+		&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080},
+		&net.TCPAddr{IP: net.ParseIP("::1"), Port: 22},
+		hostPortAddr("db.internal:5432"),
+	} {
+		got, _, err := readSocksAddr(bytes.NewReader(appendSocksAddr(nil, addr)))
+		if err != nil {
+			t.Fatalf("%s: %v", addr, err)
+		}
+		if got.String() != addr.String() {
+			t.Errorf("round trip %s = %s", addr, got)
+		}
+	}
+}
+
+func TestParseSocksUDP(t *testing.T) {
+	pkt := append([]byte{0, 0, 0, socksAtypDomain, 8}, "dns.test"...)
+	pkt = append(pkt, 0, 53)
+	pkt = append(pkt, "query"...)
+	target, payload, err := parseSocksUDP(pkt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.String() != "dns.test:53" || string(payload) != "query" {
+		t.Errorf("got %s %q", target, payload)
+	}
+
+	ipv4 := []byte{0, 0, 0, socksAtypIPv4, 8, 8, 4, 4, 0, 53, 0xab}
+	if target, payload, err = parseSocksUDP(ipv4); err != nil || target.String() != "8.8.4.4:53" || !bytes.Equal(payload, []byte{0xab}) {
+		t.Errorf("ipv4: %s %x %v", target, payload, err)
+	}
+
+	frag := append([]byte(nil), ipv4...)
+	frag[2] = 1
+	if _, _, err := parseSocksUDP(frag); err == nil {
+		t.Error("fragment accepted")
+	}
+	if _, _, err := parseSocksUDP([]byte{0, 0, 0}); err == nil {
+		t.Error("short datagram accepted")
+	}
+	if _, _, err := parseSocksUDP([]byte{0, 0, 0, 0x09, 1, 2}); err == nil {
+		t.Error("bad address type accepted")
+	}
+}
@@ -1,28 +1,21 @@
 package ssh
 
 import (
-	"bufio"
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/url"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 )
 
-type Mnitoer struct {
-}
-
-type Retary struct {
-}
-
 type Sshobject struct {
 	Label  string
 	Host   string
@@ -31,10 +24,64 @@ type Sshobject struct {
 	Pem    string
 	M      Mnitoer
 	R      Retary
+	KA     Keepalive
 	config *ssh.ClientConfig
 	client *ssh.Client
 	P      Proxy
 	Ftp    *sftp.Client
+
+	// chans are extra interactive shells sharing the connection.
+	chans channelSet
+
+	// ShareKey pools the connection with every other Sshobject of the same
+	// Identity, so they authenticate once; empty means a private connection.
+	// Connecting resets it to the current Identity.
+	ShareKey string
+	shared   *sharedClient
+
+	// Jumps is the ordered ProxyJump chain; Jumps[0] is dialed first.
+	Jumps []*Sshobject
+	hops  []*ssh.Client
+
+	// KnownHosts is the known_hosts file new host keys are written to;
+	// empty means DefaultKnownHostsPath.
+	KnownHosts string
+	// HostKeyPrompt is asked to confirm unknown host keys. When nil,
+	// unknown hosts are rejected.
+	HostKeyPrompt HostKeyPrompt
+
+	// KeyboardPrompt answers keyboard-interactive challenges such as
+	// one-time codes. When nil, only Passwd can answer password prompts.
+	KeyboardPrompt KeyboardInteractivePrompt
+
+	// ForwardAgent requests ssh-agent forwarding on interactive sessions.
+	ForwardAgent bool
+
+	// ForwardX11 requests X11 forwarding to the local DISPLAY on
+	// interactive sessions.
+	ForwardX11 bool
+
+	// Pty configures the terminal and program of interactive sessions.
+	Pty PtyOptions
+
+	// OnLatency receives each keepalive round-trip time.
+	OnLatency func(rtt time.Duration)
+	latency   atomic.Int64
+
+	auth     string
+	agent    *agentConn
+	agentFwd bool
+	x11      *x11Forwarder
+
+	hasPasswdAuth bool
+	kbdPasswdUsed bool
+
+	// signer is the private key for publickey auth; cert is its attached
+	// user certificate. hostCA is the CA that signed the last verified host
+	// certificate.
+	signer ssh.Signer
+	cert   *ssh.Certificate
+	hostCA string
 }
 
 // CreateClient establishes the SSH connection for the given object.
@@ -94,112 +141,241 @@ func SetProxy(s *Sshobject, v string) error {
 	return nil
 }
 
-func InitWithPasswd(host, user, passwd string) *Sshobject {
-	config := &ssh.ClientConfig{
-		Timeout: 30 * time.Second,
-		User:    user,
-		Auth: []ssh.AuthMethod{
-			ssh.Password(passwd),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), //  fixhostkey(defaultKnownHostsPath),
+// AddJumpHost appends a ProxyJump hop to s. The hop carries its own auth and
+// must be created with InitWithPasswd or InitWithPem. Hops are dialed in the
+// order they were added, the first one through the configured proxy if any.
+func AddJumpHost(s *Sshobject, hop *Sshobject) error {
+	if s == nil || hop == nil {
+		return fmt.Errorf("nil ssh object")
 	}
+	if hop.config == nil {
+		return fmt.Errorf("SSH config not initialized for jump host %s", hop.Host)
+	}
+	s.Jumps = append(s.Jumps, hop)
+	return nil
+}
 
-	return &Sshobject{
+// ClearJumpHosts removes all ProxyJump hops; the next connect dials directly.
+func ClearJumpHosts(s *Sshobject) {
+	if s == nil {
+		return
+	}
+	s.Jumps = nil
+}
+
+func InitWithPasswd(host, user, passwd string) *Sshobject {
+	obj := &Sshobject{
 		Host:   host,
 		User:   user,
 		Passwd: passwd,
-		config: config,
+		auth:   AuthPassword,
 	}
+	obj.config = &ssh.ClientConfig{
+		Timeout:         30 * time.Second,
+		User:            user,
+		Auth:            obj.authMethods(obj.passwordAuth()),
+		HostKeyCallback: obj.verifyHostKey,
+	}
+	return obj
 }
 
-func InitWithPem(host, user string, pem []byte) *Sshobject {
-	signer, err := ssh.ParsePrivateKey(pem)
+// InitWithPem creates an Sshobject that authenticates with a private key.
+// Encrypted keys ask for their passphrase through ask; parse failures are
+// returned instead of producing an object without a config.
+func InitWithPem(host, user string, pem []byte, ask PassphrasePrompt) (*Sshobject, error) {
+	signer, err := ParsePrivateKey(pem, user+"@"+host, ask)
 	if err != nil {
-		if fl, e := NewFileLogger("log.txt"); e == nil && fl != nil {
-			fl.Errorf("Private key parse failed: %v", err)
-		}
-		return &Sshobject{Host: host, User: user, Pem: ""}
-	}
-
-	config := &ssh.ClientConfig{
-		Timeout: 30 * time.Second,
-		User:    user,
-		Auth: []ssh.AuthMethod{
-			ssh.PublicKeys(signer),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), //fixhostkey(defaultKnownHostsPath),
+		LogErrorf("Private key parse failed: %v", err)
+		return nil, err
 	}
+	return initWithSigner(host, user, signer), nil
+}
 
-	return &Sshobject{
+// initWithSigner creates an Sshobject that authenticates with signer.
+func initWithSigner(host, user string, signer ssh.Signer) *Sshobject {
+	obj := &Sshobject{
 		Host:   host,
 		User:   user,
 		Pem:    "",
-		config: config,
+		auth:   AuthPublicKey,
+		signer: signer,
+	}
+	obj.config = &ssh.ClientConfig{
+		Timeout:         30 * time.Second,
+		User:            user,
+		Auth:            obj.authMethods(ssh.PublicKeys(signer)),
+		HostKeyCallback: obj.verifyHostKey,
 	}
+	return obj
 }
 
 // createClientImpl contains the original implementation for creating the client.
 // It is used by both the top-level CreateClient function and the legacy method wrapper.
+// When jump hosts are configured, each hop is dialed through the previous one
+// and only the first hop uses the configured proxy.
 func (s *Sshobject) createClientImpl() error {
 	LogInfof("SSH connecting to %s", s.Host)
 	if s.Ftp != nil {
 		_ = s.Ftp.Close()
 		s.Ftp = nil
 	}
+	s.releaseClient()
+	s.kbdPasswdUsed = false
 	if s.config == nil {
 		err := fmt.Errorf("SSH config not initialized for %s", s.Host)
-		LogErrorf(err.Error())
+		LogErrorf("%v", err)
 		return err
 	}
-	// Use user-configured proxy if provided; otherwise connect directly.
-	if ps := strings.TrimSpace(s.P.URL); ps != "" {
-		proxyURL, perr := url.Parse(ps)
-		if perr != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
-			err := fmt.Errorf("invalid proxy URL '%s': %v", ps, perr)
-			LogErrorf(err.Error())
+	for i, h := range s.Jumps {
+		if h == nil || h.config == nil {
+			err := fmt.Errorf("jump host %d not initialized", i+1)
+			LogErrorf("%v", err)
 			return err
 		}
-		c, err := dialThroughProxy(proxyURL, s.Host)
+	}
+
+	s.agentFwd, s.x11 = false, nil
+	if s.ShareKey == "" {
+		client, hops, route, err := s.dial()
 		if err != nil {
-			e := fmt.Errorf("proxy connect failed via %s: %v", proxyURL.String(), err)
-			LogErrorf(e.Error())
-			return e
+			return err
 		}
-		// Handshake SSH over the established tunnel
-		conn, chans, reqs, err := ssh.NewClientConn(c, s.Host, s.config)
+		s.client, s.hops = client, hops
+		s.startKeepalive(s.client)
+		LogInfof("SSH connected to %s %s", s.Host, route)
+		return nil
+	}
+
+	// The key is rederived here so it covers the credentials in use now,
+	// not those set when multiplexing was turned on.
+	s.ShareKey = Identity(s)
+	sc, owner := sharedClients.acquire(s.ShareKey)
+	if !owner {
+		s.client, s.shared, s.hostCA = sc.client, sc, sc.hostCA
+		sc.subscribe(s)
+		LogInfof("SSH reusing connection %s", s.ShareKey)
+		return nil
+	}
+	client, hops, route, err := s.dial()
+	sc.hostCA = s.hostCA
+	sharedClients.finish(sc, client, hops, err)
+	if err != nil {
+		sharedClients.release(sc)
+		return err
+	}
+	s.client, s.shared = client, sc
+	sc.subscribe(s)
+	keepalive(client, s.KA, s.Host, sc.reportLatency)
+	LogInfof("SSH connected to %s %s (shared as %s)", s.Host, route, s.ShareKey)
+	return nil
+}
+
+// dial connects through the jump chain and returns the final client, the
+// intermediate hop clients and a description of the route.
+func (s *Sshobject) dial() (*ssh.Client, []*ssh.Client, string, error) {
+	chain := append(append([]*Sshobject{}, s.Jumps...), s)
+	var hops []*ssh.Client
+	var via *ssh.Client
+	viaHost, route := "", ""
+	for _, node := range chain {
+		c, r, err := s.dialHop(via, viaHost, node)
+		if err != nil {
+			closeClients(hops)
+			LogErrorf("%v", err)
+			return nil, nil, "", err
+		}
+		node.config.HostKeyAlgorithms = node.hostKeyAlgorithms(node.Host)
+		conn, chans, reqs, err := ssh.NewClientConn(c, node.Host, node.config)
 		if err != nil {
 			_ = c.Close()
-			e := fmt.Errorf("SSH handshake failed via proxy %s: %v", proxyURL.String(), err)
-			LogErrorf(e.Error())
-			return e
+			closeClients(hops)
+			e := fmt.Errorf("SSH handshake failed %s: %v", r, err)
+			LogErrorf("%v", e)
+			return nil, nil, "", e
 		}
-		s.client = ssh.NewClient(conn, chans, reqs)
-		LogInfof("SSH connected to %s via proxy %s", s.Host, proxyURL.String())
-		return nil
+		via, viaHost = ssh.NewClient(conn, chans, reqs), node.Host
+		hops = append(hops, via)
+		route = r
+	}
+	return hops[len(hops)-1], hops[:len(hops)-1], route, nil
+}
+
+// dialHop opens the transport for node. When via is set the connection is
+// made through that previous hop, whose configured address viaHost names
+// the route; otherwise it goes through the configured proxy, or directly
+// when there is none. It also returns a short route description for logs
+// and errors.
+func (s *Sshobject) dialHop(via *ssh.Client, viaHost string, node *Sshobject) (net.Conn, string, error) {
+	if via != nil {
+		c, err := via.Dial("tcp", node.Host)
+		if err != nil {
+			return nil, "", fmt.Errorf("jump connect failed to %s: %v", node.Host, err)
+		}
+		return c, "via jump " + viaHost, nil
+	}
+	// Use user-configured proxy if provided; otherwise connect directly.
+	if ps := strings.TrimSpace(s.P.URL); ps != "" {
+		proxyURL, perr := url.Parse(ps)
+		if perr != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, "", fmt.Errorf("invalid proxy URL '%s': %v", ps, perr)
+		}
+		c, err := dialThroughProxy(proxyURL, node.Host)
+		if err != nil {
+			return nil, "", fmt.Errorf("proxy connect failed via %s: %v", proxyURL.String(), err)
+		}
+		return c, "via proxy " + proxyURL.String(), nil
 	}
 
 	// Fallback: direct connection
-	client, err := ssh.Dial("tcp", s.Host, s.config)
+	c, err := net.DialTimeout("tcp", node.Host, node.config.Timeout)
 	if err != nil {
-		e := fmt.Errorf("direct connect failed to %s: %v", s.Host, err)
-		LogErrorf(e.Error())
-		return e
+		return nil, "", fmt.Errorf("direct connect failed to %s: %v", node.Host, err)
 	}
-	s.client = client
-	LogInfof("SSH connected to %s (direct)", s.Host)
-	return nil
+	return c, "(direct)", nil
 }
 
 // closeImpl contains the original implementation for closing the client.
+// The target client is closed first, then each jump hop from the nearest to
+// the target back to the first one.
 func (s *Sshobject) closeImpl() {
+	StopMonitor(s)
+	s.closeChannels()
 	if s.Ftp != nil {
 		_ = s.Ftp.Close()
 		s.Ftp = nil
 	}
+	s.releaseClient()
+	s.agent.close()
+}
+
+// releaseClient closes a private connection or drops the reference to a
+// shared one.
+func (s *Sshobject) releaseClient() {
+	if s.shared != nil {
+		s.shared.unsubscribe(s)
+		sharedClients.release(s.shared)
+		s.shared, s.client = nil, nil
+		return
+	}
 	if s.client != nil {
 		_ = s.client.Close()
 		s.client = nil
 	}
+	s.closeHops()
+}
+
+// closeHops tears down the jump chain in reverse dial order.
+func (s *Sshobject) closeHops() {
+	closeClients(s.hops)
+	s.hops = nil
+}
+
+func closeClients(clients []*ssh.Client) {
+	for i := len(clients) - 1; i >= 0; i-- {
+		if clients[i] != nil {
+			_ = clients[i].Close()
+		}
+	}
 }
 
 // // 轮询本地窗口尺寸，变化时通知远端
@@ -233,9 +409,15 @@ type StreamSession struct {
 	sess   *ssh.Session
 	stdinW *io.PipeWriter
 	done   chan struct{}
+
+	mu         sync.Mutex
+	rows, cols int
+	err        error
+	rec        *Recorder
 }
 
-// StartStream starts an interactive shell, wiring stdout/stderr to out.
+// StartStream starts an interactive shell, or the command or subsystem set
+// in s.Pty, wiring stdout/stderr to out.
 // Returns a StreamSession whose Write method sends data to remote stdin.
 func StartStream(s *Sshobject, out io.Writer, rows, cols int) (*StreamSession, error) {
 	if s == nil || s.client == nil {
@@ -254,33 +436,60 @@ func StartStream(s *Sshobject, out io.Writer, rows, cols int) (*StreamSession, e
 	if cols <= 0 {
 		cols = 120
 	}
-	modes := ssh.TerminalModes{
-		ssh.ECHO:          1,
-		ssh.TTY_OP_ISPEED: 14400,
-		ssh.TTY_OP_OSPEED: 14400,
+	modes, err := s.Pty.terminalModes()
+	if err != nil {
+		_ = sess.Close()
+		return nil, err
 	}
-	if err := sess.RequestPty("xterm-256color", rows, cols, modes); err != nil {
+	if err := sess.RequestPty(s.Pty.term(), rows, cols, modes); err != nil {
 		_ = sess.Close()
 		return nil, err
 	}
+	s.Pty.setenv(sess, s.Host)
+
+	if s.ForwardAgent {
+		if err := s.requestAgentForwarding(sess); err != nil {
+			LogErrorf("%v", err)
+			_ = sess.Close()
+			return nil, err
+		}
+	}
+	var x11 *x11Forwarder
+	if s.ForwardX11 {
+		// Like ssh(1), a refused or impossible X11 request only warns:
+		// sshd defaults to X11Forwarding no.
+		if x11, err = s.requestX11Forwarding(sess); err != nil {
+			LogErrorf("%v; continuing without X11 (host=%s)", err, s.Host)
+			if out != nil {
+				fmt.Fprintf(out, "Warning: %v\r\n", err)
+			}
+			x11 = nil
+		}
+	}
 
 	pr, pw := io.Pipe()
+	st := &StreamSession{sess: sess, stdinW: pw, done: make(chan struct{}), rows: rows, cols: cols}
+	rw := &recordingWriter{st: st, out: out}
 	sess.Stdin = pr
-	sess.Stdout = out
-	sess.Stderr = out
+	sess.Stdout = rw
+	sess.Stderr = rw
 
-	if err := sess.Shell(); err != nil {
+	if err := s.Pty.start(sess); err != nil {
+		x11.sessionEnded()
 		_ = sess.Close()
 		_ = pw.Close()
 		return nil, err
 	}
 	// Wait in background so remote can run until closed; clean pipe when done.
-	st := &StreamSession{sess: sess, stdinW: pw, done: make(chan struct{})}
 	go func() {
-		_ = sess.Wait()
+		err := sess.Wait()
+		st.mu.Lock()
+		st.err = err
+		st.mu.Unlock()
 		_ = pw.Close()
 		_ = pr.Close()
 		_ = sess.Close()
+		x11.sessionEnded()
 		LogInfof("SSH connection to %s has benn closed", s.Host)
 		close(st.done)
 	}()
@@ -309,7 +518,38 @@ func (st *StreamSession) Resize(rows, cols int) error {
 	if st == nil || st.sess == nil {
 		return fmt.Errorf("session closed")
 	}
-	return st.sess.WindowChange(rows, cols)
+	if err := st.sess.WindowChange(rows, cols); err != nil {
+		return err
+	}
+	st.mu.Lock()
+	st.rows, st.cols = rows, cols
+	rec := st.rec
+	st.mu.Unlock()
+	rec.Resize(rows, cols)
+	return nil
+}
+
+// Size returns the last PTY size requested for the session.
+func (st *StreamSession) Size() (rows, cols int) {
+	if st == nil {
+		return 0, 0
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.rows, st.cols
+}
+
+// Dropped reports whether the session ended without the remote shell
+// exiting, i.e. the connection was lost. Only meaningful after Done.
+func (st *StreamSession) Dropped() bool {
+	if st == nil {
+		return false
+	}
+	st.mu.Lock()
+	err := st.err
+	st.mu.Unlock()
+	var exitErr *ssh.ExitError
+	return err != nil && !errors.As(err, &exitErr)
 }
 
 // Done returns a channel that is closed when the remote shell exits.
@@ -333,60 +573,6 @@ func (s *Sshobject) Close() { Close(s) }
 
 // stdLogger type moved to log.go
 
-// Known hosts storage (relative to repo root by default)
-const defaultKnownHostsPath = "internal/ssh/known_hosts"
-
-// fixhostkey returns an ssh.HostKeyCallback which checks the fingerprint in known_hosts
-// and, if unknown, prompts the user to confirm and saves it.
-func fixhostkey(path string) ssh.HostKeyCallback {
-	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-		fp := ssh.FingerprintSHA256(key)
-		if hasFingerprint(path, fp) {
-			return nil
-		}
-		fmt.Printf("Unknown host key for %s (%s)\nFingerprint (SHA256): %s\nTrust this host and add to %s? (yes/no): ", hostname, remote.String(), fp, path)
-		br := bufio.NewReader(os.Stdin)
-		line, _ := br.ReadString('\n')
-		line = strings.TrimSpace(strings.ToLower(line))
-		if line != "yes" && line != "y" {
-			return fmt.Errorf("host key not accepted by user")
-		}
-		if dir := filepath.Dir(path); dir != "." && dir != "" {
-			_ = os.MkdirAll(dir, 0o755)
-		}
-		hostField := normalizeKnownHostName(hostname)
-		// OpenSSH known_hosts line: hostnames keytype base64key  # include fingerprint as comment for quick lookup
-		lineToAdd := fmt.Sprintf("%s %s %s # %s\n", hostField, key.Type(), base64.StdEncoding.EncodeToString(key.Marshal()), fp)
-		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
-		if err != nil {
-			return fmt.Errorf("failed to write known_hosts: %w", err)
-		}
-		defer f.Close()
-		if _, err := f.WriteString(lineToAdd); err != nil {
-			return fmt.Errorf("failed to append known_hosts: %w", err)
-		}
-		return nil
-	}
-}
-
-func normalizeKnownHostName(hostname string) string {
-	if strings.HasPrefix(hostname, "[") && strings.Contains(hostname, "]:") {
-		return hostname
-	}
-	if strings.Contains(hostname, ":") {
-		return "[" + hostname + "]"
-	}
-	return hostname
-}
-
-func hasFingerprint(path, fingerprint string) bool {
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return false
-	}
-	return strings.Contains(string(b), fingerprint)
-}
-
 // normalizeProxyURL validates and normalizes a proxy URL.
 // If scheme is missing, defaultScheme is prepended. Only schemes in allowed are accepted.
 func normalizeProxyURL(raw, defaultScheme string, allowed ...string) (*url.URL, error) {
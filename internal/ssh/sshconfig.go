@@ -0,0 +1,528 @@
+package ssh
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ForwardSpec is a LocalForward/RemoteForward pair taken from ssh_config.
+type ForwardSpec struct {
+	Bind   string `json:"bind"`
+	Target string `json:"target"`
+}
+
+// HostProfile is one concrete Host entry resolved from an OpenSSH client
+// config, with `Host *` and other wildcard blocks already applied.
+type HostProfile struct {
+	Alias           string        `json:"alias"`
+	HostName        string        `json:"hostName"`
+	Port            int           `json:"port"`
+	User            string        `json:"user,omitempty"`
+	IdentityFiles   []string      `json:"identityFiles,omitempty"`
+	CertFiles       []string      `json:"certificateFiles,omitempty"`
+	ProxyJump       []string      `json:"proxyJump,omitempty"`
+	Jumps           []HostProfile `json:"jumps,omitempty"`
+	LocalForwards   []ForwardSpec `json:"localForwards,omitempty"`
+	RemoteForwards  []ForwardSpec `json:"remoteForwards,omitempty"`
+	DynamicForwards []string      `json:"dynamicForwards,omitempty"`
+	ForwardAgent    bool          `json:"forwardAgent,omitempty"`
+	// ServerAliveInterval is in seconds; ServerAliveCountMax is the number
+	// of unanswered keepalives before the connection is dropped.
+	ServerAliveInterval int `json:"serverAliveInterval,omitempty"`
+	ServerAliveCountMax int `json:"serverAliveCountMax,omitempty"`
+}
+
+// Addr returns the host:port the profile connects to.
+func (p *HostProfile) Addr() string {
+	port := p.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(p.HostName, strconv.Itoa(port))
+}
+
+// SSHConfig is a parsed OpenSSH client config with Include files inlined.
+type SSHConfig struct {
+	directives []configDirective
+	aliases    []string
+}
+
+// configDirective is one keyword line together with the Host patterns that
+// were active when it was read. A nil pattern list means "applies to all".
+type configDirective struct {
+	key      string
+	args     []string
+	patterns []string
+}
+
+// maxIncludeDepth mirrors OpenSSH's READCONF_MAX_DEPTH.
+const maxIncludeDepth = 16
+
+// DefaultSSHConfigPath returns ~/.ssh/config.
+func DefaultSSHConfigPath() string {
+	return filepath.Join(sshUserDir(), "config")
+}
+
+func sshUserDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		home = "."
+	}
+	return filepath.Join(home, ".ssh")
+}
+
+// ParseSSHConfig reads an OpenSSH client config file, following Include
+// directives. An empty path means DefaultSSHConfigPath.
+func ParseSSHConfig(path string) (*SSHConfig, error) {
+	if strings.TrimSpace(path) == "" {
+		path = DefaultSSHConfigPath()
+	}
+	cfg := &SSHConfig{}
+	if err := cfg.readFile(path, nil, 0); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *SSHConfig) readFile(path string, patterns []string, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("ssh config: include nested too deeply at %s", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	lineNum := 0
+	for sc.Scan() {
+		lineNum++
+		key, args, err := splitConfigLine(sc.Text())
+		if err != nil {
+			return fmt.Errorf("ssh config: %s:%d: %v", path, lineNum, err)
+		}
+		if key == "" {
+			continue
+		}
+		switch key {
+		case "host":
+			if len(args) == 0 {
+				return fmt.Errorf("ssh config: %s:%d: Host without patterns", path, lineNum)
+			}
+			patterns = args
+			for _, a := range args {
+				if isConcreteAlias(a) && !containsFold(c.aliases, a) {
+					c.aliases = append(c.aliases, a)
+				}
+			}
+		case "match":
+			// Match criteria are not evaluated; its block never applies.
+			patterns = []string{"!*"}
+		case "include":
+			for _, arg := range args {
+				matches, err := expandInclude(arg)
+				if err != nil {
+					return fmt.Errorf("ssh config: %s:%d: %v", path, lineNum, err)
+				}
+				for _, m := range matches {
+					// Host lines inside an included file only affect that file.
+					if err := c.readFile(m, patterns, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+		default:
+			c.directives = append(c.directives, configDirective{key: key, args: args, patterns: patterns})
+		}
+	}
+	return sc.Err()
+}
+
+// expandInclude resolves an Include argument: ~ is expanded, relative paths
+// are taken from ~/.ssh, and glob patterns may match zero or more files.
+func expandInclude(arg string) ([]string, error) {
+	p := expandTilde(arg)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(sshUserDir(), p)
+	}
+	return filepath.Glob(p)
+}
+
+// splitConfigLine splits "Keyword args..." or "Keyword=args" honouring double
+// quotes. Comments and blank lines yield an empty keyword.
+func splitConfigLine(line string) (string, []string, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil, nil
+	}
+	end := strings.IndexAny(line, " \t=")
+	if end < 0 {
+		return strings.ToLower(line), nil, nil
+	}
+	key := strings.ToLower(line[:end])
+	rest := strings.TrimLeft(line[end:], " \t")
+	if strings.HasPrefix(rest, "=") {
+		rest = strings.TrimLeft(rest[1:], " \t")
+	}
+
+	var args []string
+	var cur strings.Builder
+	inQuote, have := false, false
+	for _, r := range rest {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			have = true
+		case !inQuote && (r == ' ' || r == '\t'):
+			if have {
+				args = append(args, cur.String())
+				cur.Reset()
+				have = false
+			}
+		case !inQuote && r == '#' && !have:
+			// trailing comment
+			return key, args, nil
+		default:
+			cur.WriteRune(r)
+			have = true
+		}
+	}
+	if inQuote {
+		return "", nil, fmt.Errorf("unterminated quote")
+	}
+	if have {
+		args = append(args, cur.String())
+	}
+	return key, args, nil
+}
+
+// Hosts lists every concrete (non-wildcard) Host alias in file order.
+func (c *SSHConfig) Hosts() []string {
+	return append([]string(nil), c.aliases...)
+}
+
+// Profiles resolves every concrete Host alias into a HostProfile.
+func (c *SSHConfig) Profiles() []HostProfile {
+	out := make([]HostProfile, 0, len(c.aliases))
+	for _, a := range c.aliases {
+		out = append(out, c.Resolve(a))
+	}
+	return out
+}
+
+// Resolve computes the effective settings for alias the way ssh(1) does: the
+// first value seen for a keyword wins, while IdentityFile and the forward
+// keywords accumulate across every matching block.
+func (c *SSHConfig) Resolve(alias string) HostProfile {
+	return c.resolve(alias, 0)
+}
+
+func (c *SSHConfig) resolve(alias string, depth int) HostProfile {
+	p := HostProfile{Alias: alias}
+	seen := map[string]bool{}
+	first := func(key string) bool {
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	}
+
+	for _, d := range c.directives {
+		if !matchHostPatterns(d.patterns, alias) || len(d.args) == 0 {
+			continue
+		}
+		switch d.key {
+		case "hostname":
+			if first(d.key) {
+				p.HostName = strings.ReplaceAll(d.args[0], "%h", alias)
+			}
+		case "port":
+			if first(d.key) {
+				p.Port, _ = strconv.Atoi(d.args[0])
+			}
+		case "user":
+			if first(d.key) {
+				p.User = d.args[0]
+			}
+		case "proxyjump":
+			if first(d.key) && !strings.EqualFold(d.args[0], "none") {
+				for _, hop := range strings.Split(strings.Join(d.args, ","), ",") {
+					if hop = strings.TrimSpace(hop); hop != "" {
+						p.ProxyJump = append(p.ProxyJump, hop)
+					}
+				}
+			}
+		case "identityfile":
+			if !strings.EqualFold(d.args[0], "none") {
+				p.IdentityFiles = append(p.IdentityFiles, d.args[0])
+			}
+		case "certificatefile":
+			if !strings.EqualFold(d.args[0], "none") {
+				p.CertFiles = append(p.CertFiles, d.args[0])
+			}
+		case "localforward":
+			if len(d.args) >= 2 {
+				p.LocalForwards = append(p.LocalForwards, ForwardSpec{Bind: forwardBind(d.args[0]), Target: d.args[1]})
+			}
+		case "remoteforward":
+			if len(d.args) >= 2 {
+				p.RemoteForwards = append(p.RemoteForwards, ForwardSpec{Bind: forwardBind(d.args[0]), Target: d.args[1]})
+			}
+		case "dynamicforward":
+			p.DynamicForwards = append(p.DynamicForwards, forwardBind(d.args[0]))
+		case "serveraliveinterval":
+			if first(d.key) {
+				p.ServerAliveInterval, _ = strconv.Atoi(d.args[0])
+			}
+		case "serveralivecountmax":
+			if first(d.key) {
+				p.ServerAliveCountMax, _ = strconv.Atoi(d.args[0])
+			}
+		case "forwardagent":
+			if first(d.key) {
+				p.ForwardAgent = strings.EqualFold(d.args[0], "yes")
+			}
+		}
+	}
+
+	if p.HostName == "" {
+		p.HostName = alias
+	}
+	if p.Port == 0 {
+		p.Port = 22
+	}
+	var ids []string
+	for _, f := range p.IdentityFiles {
+		if f = expandTokens(f, &p); !containsFold(ids, f) {
+			ids = append(ids, f)
+		}
+	}
+	p.IdentityFiles = ids
+	var certs []string
+	for _, f := range p.CertFiles {
+		if f = expandTokens(f, &p); !containsFold(certs, f) {
+			certs = append(certs, f)
+		}
+	}
+	p.CertFiles = certs
+	if depth < maxIncludeDepth {
+		for _, hop := range p.ProxyJump {
+			p.Jumps = append(p.Jumps, c.resolveJump(hop, depth+1))
+		}
+	}
+	return p
+}
+
+// resolveJump resolves a ProxyJump element of the form [user@]host[:port],
+// where host may itself be an alias defined in the config.
+func (c *SSHConfig) resolveJump(spec string, depth int) HostProfile {
+	userPart, host := "", spec
+	if at := strings.LastIndex(spec, "@"); at >= 0 {
+		userPart, host = spec[:at], spec[at+1:]
+	}
+	port := 0
+	if h, ps, err := net.SplitHostPort(host); err == nil {
+		host = h
+		port, _ = strconv.Atoi(ps)
+	}
+	p := c.resolve(host, depth)
+	if userPart != "" {
+		p.User = userPart
+	}
+	if port != 0 {
+		p.Port = port
+	}
+	return p
+}
+
+// forwardBind turns "[bind_address:]port" into a listen address, binding to
+// localhost when no address is given as ssh(1) does by default.
+func forwardBind(v string) string {
+	if _, err := strconv.Atoi(v); err == nil {
+		return net.JoinHostPort("localhost", v)
+	}
+	if strings.HasPrefix(v, "*:") {
+		return v[1:]
+	}
+	return v
+}
+
+// expandTokens expands ~ and the %d, %h, %r, %u and %% tokens in a path.
+func expandTokens(v string, p *HostProfile) string {
+	v = expandTilde(v)
+	if !strings.Contains(v, "%") {
+		return v
+	}
+	home, _ := os.UserHomeDir()
+	local := ""
+	if u, err := user.Current(); err == nil {
+		local = u.Username
+	}
+	r := strings.NewReplacer("%%", "%", "%d", home, "%h", p.HostName, "%r", p.User, "%u", local)
+	return r.Replace(v)
+}
+
+func expandTilde(v string) string {
+	if v == "~" || strings.HasPrefix(v, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, v[1:])
+		}
+	}
+	return v
+}
+
+// matchHostPatterns reports whether host matches a Host pattern list: at least
+// one positive pattern must match and no negated pattern may match.
+func matchHostPatterns(patterns []string, host string) bool {
+	if patterns == nil {
+		return true
+	}
+	matched := false
+	for _, p := range patterns {
+		if strings.HasPrefix(p, "!") {
+			if wildcardMatch(strings.ToLower(p[1:]), strings.ToLower(host)) {
+				return false
+			}
+			continue
+		}
+		if wildcardMatch(strings.ToLower(p), strings.ToLower(host)) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// wildcardMatch implements the `*` and `?` globbing used by ssh_config.
+func wildcardMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if wildcardMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if s == "" {
+				return false
+			}
+		default:
+			if s == "" || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return s == ""
+}
+
+func isConcreteAlias(p string) bool {
+	return !strings.ContainsAny(p, "*?!")
+}
+
+func containsFold(list []string, v string) bool {
+	for _, x := range list {
+		if strings.EqualFold(x, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// InitFromProfile creates an Sshobject for p, including its ProxyJump hops.
+// Each node authenticates with its first readable IdentityFile, falling back
+// to the ssh-agent or default identities when none can be read and no passwd
+// is given, and to passwd otherwise. Encrypted IdentityFiles ask for their
+// passphrase through ask.
+func InitFromProfile(p HostProfile, passwd string, ask PassphrasePrompt) (*Sshobject, error) {
+	obj, err := initProfileNode(p, passwd, ask)
+	if err != nil {
+		return nil, err
+	}
+	obj.Label = p.Alias
+	obj.ForwardAgent = p.ForwardAgent && AgentSocket() != ""
+	obj.KA = Keepalive{
+		Interval:  time.Duration(p.ServerAliveInterval) * time.Second,
+		MaxMissed: p.ServerAliveCountMax,
+	}
+	for _, hop := range p.Jumps {
+		h, err := initProfileNode(hop, passwd, ask)
+		if err != nil {
+			return nil, fmt.Errorf("jump host %s: %w", hop.Alias, err)
+		}
+		if err := AddJumpHost(obj, h); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// attachProfileCert attaches the first certificate in files that matches the
+// object's key, like ssh(1) pairing CertificateFile and <identity>-cert.pub.
+func attachProfileCert(obj *Sshobject, files []string) {
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		if err := SetCertificate(obj, data); err == nil {
+			return
+		}
+	}
+}
+
+func initProfileNode(p HostProfile, passwd string, ask PassphrasePrompt) (*Sshobject, error) {
+	userName := p.User
+	if userName == "" {
+		if u, err := user.Current(); err == nil {
+			userName = u.Username
+		}
+	}
+	for _, f := range p.IdentityFiles {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		signer, err := ParsePrivateKey(data, f, ask)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		obj := initWithSigner(p.Addr(), userName, signer)
+		attachProfileCert(obj, append(append([]string(nil), p.CertFiles...), f+"-cert.pub"))
+		return obj, nil
+	}
+	// Like ssh(1), fall back to the agent, then the default identities, when
+	// no listed IdentityFile could be read.
+	if passwd == "" {
+		if AgentAvailable() {
+			return InitWithAgent(p.Addr(), userName)
+		}
+		for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+			data, err := os.ReadFile(filepath.Join(sshUserDir(), name))
+			if err != nil {
+				continue
+			}
+			// Encrypted default keys are skipped rather than prompted for.
+			if signer, err := ParsePrivateKey(data, name, nil); err == nil {
+				return initWithSigner(p.Addr(), userName, signer), nil
+			}
+		}
+	}
+	return InitWithPasswd(p.Addr(), userName, passwd), nil
+}
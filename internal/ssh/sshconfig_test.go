@@ -0,0 +1,256 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testHome points HOME at a fresh directory with an empty ~/.ssh and
+// returns that .ssh directory.
+func testHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".ssh")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func parseConfig(t *testing.T, dir, text string) *SSHConfig {
+	t.Helper()
+	path := filepath.Join(dir, "config")
+	writeFile(t, path, text)
+	cfg, err := ParseSSHConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestSplitConfigLine(t *testing.T) {
+	tests := []struct {
+		line string
+		key  string
+		args []string
+	}{
+		{"Host web db", "host", []string{"web", "db"}},
+		{"  HostName=example.com", "hostname", []string{"example.com"}},
+		{"User = bob", "user", []string{"bob"}},
+		{"Port\t2222", "port", []string{"2222"}},
+		{`IdentityFile "/keys/my key"`, "identityfile", []string{"/keys/my key"}},
+		{`LocalForward 8080 "localhost:80" # web`, "localforward", []string{"8080", "localhost:80"}},
+		{"ForwardAgent", "forwardagent", nil},
+		{"# comment", "", nil},
+		{"   ", "", nil},
+	}
+	for _, tt := range tests {
+		key, args, err := splitConfigLine(tt.line)
+		if err != nil {
+			t.Errorf("%q: %v", tt.line, err)
+			continue
+		}
+		if key != tt.key || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%q = %q %q, want %q %q", tt.line, key, args, tt.key, tt.args)
+		}
+	}
+	if _, _, err := splitConfigLine(`ProxyCommand "nc %h`); err == nil {
+		t.Error("unterminated quote accepted")
+	}
+}
+
+func TestMatchHostPatterns(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		host     string
+		want     bool
+	}{
+		{nil, "any", true},
+		{[]string{"*"}, "web", true},
+		{[]string{"web?"}, "web1", true},
+		{[]string{"web?"}, "web10", false},
+		{[]string{"*.example.com"}, "Git.Example.com", true},
+		{[]string{"*.example.com", "!bastion.example.com"}, "web.example.com", true},
+		{[]string{"*.example.com", "!bastion.example.com"}, "bastion.example.com", false},
+		{[]string{"!bastion"}, "web", false}, // negation alone matches nothing
+		{[]string{"!*"}, "web", false},
+	}
+	for _, tt := range tests {
+		if got := matchHostPatterns(tt.patterns, tt.host); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.patterns, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := testHome(t)
+	cfg := parseConfig(t, dir, `
+Host web
+    HostName web.internal
+    User alice
+    IdentityFile ~/.ssh/web_key
+    LocalForward 8080 localhost:80
+
+Host *.internal !db.internal
+    User nobody
+
+Host web db
+    Port 2222
+    IdentityFile /keys/%r@%h
+    ProxyJump bastion
+
+Host bastion
+    HostName bastion.example.com
+    User jump
+    Port 2200
+
+Host multi
+    ProxyJump root@bastion:2022,plain
+
+Match host web
+    User matched
+
+Host *
+    User default
+    Port 22
+    IdentityFile /keys/common
+    ServerAliveInterval 30
+`)
+	if got, want := cfg.Hosts(), []string{"web", "db", "bastion", "multi"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("hosts = %q, want %q", got, want)
+	}
+
+	web := cfg.Resolve("web")
+	if web.HostName != "web.internal" || web.User != "alice" || web.Port != 2222 {
+		t.Errorf("web = %s@%s:%d", web.User, web.HostName, web.Port)
+	}
+	wantIDs := []string{filepath.Join(dir, "web_key"), "/keys/alice@web.internal", "/keys/common"}
+	if !reflect.DeepEqual(web.IdentityFiles, wantIDs) {
+		t.Errorf("identity files = %q, want %q", web.IdentityFiles, wantIDs)
+	}
+	if want := []ForwardSpec{{Bind: "localhost:8080", Target: "localhost:80"}}; !reflect.DeepEqual(web.LocalForwards, want) {
+		t.Errorf("local forwards = %+v", web.LocalForwards)
+	}
+	if web.ServerAliveInterval != 30 {
+		t.Errorf("server alive interval = %d", web.ServerAliveInterval)
+	}
+	if len(web.Jumps) != 1 || web.Jumps[0].Addr() != "bastion.example.com:2200" || web.Jumps[0].User != "jump" {
+		t.Errorf("jumps = %+v", web.Jumps)
+	}
+
+	db := cfg.Resolve("db")
+	if db.User != "default" || db.HostName != "db" || db.Port != 2222 {
+		t.Errorf("db = %s@%s:%d", db.User, db.HostName, db.Port)
+	}
+
+	multi := cfg.Resolve("multi")
+	if len(multi.Jumps) != 2 {
+		t.Fatalf("multi jumps = %+v", multi.Jumps)
+	}
+	if j := multi.Jumps[0]; j.User != "root" || j.Addr() != "bastion.example.com:2022" {
+		t.Errorf("first hop = %s@%s", j.User, j.Addr())
+	}
+	if j := multi.Jumps[1]; j.User != "default" || j.Addr() != "plain:22" {
+		t.Errorf("second hop = %s@%s", j.User, j.Addr())
+	}
+}
+
+func TestIncludeScoping(t *testing.T) {
+	dir := testHome(t)
+	writeFile(t, filepath.Join(dir, "inc.conf"), `
+Port 2022
+Host c
+    User from-c
+`)
+	cfg := parseConfig(t, dir, `
+Host a
+    Include inc.conf
+    User from-a
+
+Host b
+    HostName b.example
+`)
+	if a := cfg.Resolve("a"); a.Port != 2022 || a.User != "from-a" {
+		t.Errorf("a = %s:%d", a.User, a.Port)
+	}
+	if c := cfg.Resolve("c"); c.Port != 22 || c.User != "from-c" {
+		t.Errorf("c = %s:%d", c.User, c.Port)
+	}
+	if b := cfg.Resolve("b"); b.HostName != "b.example" || b.User != "" {
+		t.Errorf("b = %s@%s", b.User, b.HostName)
+	}
+	if got, want := cfg.Hosts(), []string{"a", "c", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("hosts = %q, want %q", got, want)
+	}
+}
+
+func TestInitProfileNodeFallback(t *testing.T) {
+	dir := testHome(t)
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := string(pem.EncodeToMemory(block))
+	listed := filepath.Join(t.TempDir(), "listed_key")
+	writeFile(t, listed, keyPEM)
+
+	agentSock := filepath.Join(t.TempDir(), "agent.sock")
+	ln, err := net.Listen("unix", agentSock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	missing := filepath.Join(dir, "missing_key")
+	tests := []struct {
+		name       string
+		ids        []string
+		agent      bool
+		defaultKey bool
+		passwd     string
+		want       string
+	}{
+		{"readable identity", []string{missing, listed}, true, true, "", AuthPublicKey},
+		{"unreadable identity uses agent", []string{missing}, true, true, "", AuthAgent},
+		{"unreadable identity uses default key", []string{missing}, false, true, "", AuthPublicKey},
+		{"unreadable identity with password", []string{missing}, true, true, "pw", AuthPassword},
+		{"nothing available", nil, false, false, "", AuthPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SSH_AUTH_SOCK", "")
+			if tt.agent {
+				t.Setenv("SSH_AUTH_SOCK", agentSock)
+			}
+			def := filepath.Join(dir, "id_ed25519")
+			_ = os.Remove(def)
+			if tt.defaultKey {
+				writeFile(t, def, keyPEM)
+			}
+			obj, err := initProfileNode(HostProfile{HostName: "h", Port: 22, User: "u", IdentityFiles: tt.ids}, tt.passwd, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if obj.auth != tt.want {
+				t.Errorf("auth = %s, want %s", obj.auth, tt.want)
+			}
+		})
+	}
+}
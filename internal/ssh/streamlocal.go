@@ -0,0 +1,112 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const streamLocalCleanupTimeout = 5 * time.Second
+
+// splitLocalAddr returns the network of a local forward endpoint: "unix"
+// for "unix:<path>" or an absolute path, otherwise "tcp".
+func splitLocalAddr(addr string) (network, address string) {
+	if p, ok := strings.CutPrefix(addr, "unix:"); ok {
+		return "unix", p
+	}
+	if filepath.IsAbs(addr) || strings.HasPrefix(addr, "/") {
+		return "unix", addr
+	}
+	return "tcp", addr
+}
+
+// listenLocal listens on a TCP address or unix socket path, replacing a
+// stale socket file nobody accepts on.
+func listenLocal(addr string) (net.Listener, error) {
+	network, address := splitLocalAddr(addr)
+	if network == "unix" {
+		removeStaleSocket(address)
+	}
+	return net.Listen(network, address)
+}
+
+func removeStaleSocket(path string) {
+	fi, err := os.Lstat(path)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return
+	}
+	if c, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		c.Close()
+		return
+	}
+	if err := os.Remove(path); err == nil {
+		LogInfof("Removed stale socket %s", path)
+	}
+}
+
+// LocalStreamForward forwards local (a TCP address, or a unix socket path
+// as "unix:<path>" or an absolute path) to the unix socket remoteSocket on
+// the server, using direct-streamlocal@openssh.com. A local socket file is
+// removed when the forward stops.
+func (s *Sshobject) LocalStreamForward(local, remoteSocket string) (*Forwarder, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("ssh client not started")
+	}
+	if remoteSocket == "" {
+		return nil, fmt.Errorf("remote socket path required")
+	}
+	ln, err := listenLocal(local)
+	if err != nil {
+		return nil, err
+	}
+	return s.localForward(ln, local, "unix", remoteSocket), nil
+}
+
+// RemoteStreamForward listens on the unix socket remoteSocket on the server
+// (streamlocal-forward@openssh.com) and forwards each connection to local,
+// a TCP address or unix socket path. The remote socket file is removed
+// when the forward stops.
+func (s *Sshobject) RemoteStreamForward(remoteSocket, local string) (*Forwarder, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("ssh client not started")
+	}
+	if remoteSocket == "" {
+		return nil, fmt.Errorf("remote socket path required")
+	}
+	rln, err := s.client.ListenUnix(remoteSocket)
+	if err != nil {
+		return nil, err
+	}
+	network, address := splitLocalAddr(local)
+	fw := s.remoteForward(rln, remoteSocket, network, address)
+	client, stop := s.client, fw.stop
+	fw.stop = func() error {
+		err := stop()
+		// Callers hold locks; never wait on the server here.
+		go removeRemoteSocket(client, remoteSocket)
+		return err
+	}
+	return fw, nil
+}
+
+// removeRemoteSocket unlinks a socket file left behind by a cancelled
+// streamlocal forward; sshd only does so with StreamLocalBindUnlink. A
+// connection that is already gone is left alone.
+func removeRemoteSocket(client *ssh.Client, path string) {
+	sess, err := client.NewSession()
+	if err != nil {
+		return
+	}
+	defer sess.Close()
+	t := time.AfterFunc(streamLocalCleanupTimeout, func() { _ = sess.Close() })
+	defer t.Stop()
+	q := shellQuote(path)
+	if err := sess.Run("test -S " + q + " && rm -f " + q); err == nil {
+		LogInfof("Removed remote socket %s", path)
+	}
+}
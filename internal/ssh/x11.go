@@ -0,0 +1,282 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const x11AuthProto = "MIT-MAGIC-COOKIE-1"
+
+// X11Display returns the local X display from DISPLAY.
+func X11Display() string {
+	return strings.TrimSpace(os.Getenv("DISPLAY"))
+}
+
+// SetX11Forwarding toggles X11 forwarding for interactive sessions started
+// after the call. The flag is kept even without a usable DISPLAY; that is
+// reported as a warning when a shell starts.
+func SetX11Forwarding(s *Sshobject, enabled bool) error {
+	if s == nil {
+		return fmt.Errorf("nil ssh object")
+	}
+	s.ForwardX11 = enabled
+	return nil
+}
+
+// x11Display is a parsed DISPLAY value.
+type x11Display struct {
+	network string // "unix" or "tcp"
+	addr    string
+	number  int
+	screen  int
+}
+
+// parseDisplay understands ":0", ":0.0", "unix:0", "host:10.0" and the
+// socket path form used by XQuartz, "/path/to/socket:0".
+func parseDisplay(display string) (*x11Display, error) {
+	if display == "" {
+		return nil, fmt.Errorf("DISPLAY is not set")
+	}
+	i := strings.LastIndexByte(display, ':')
+	if i < 0 {
+		return nil, fmt.Errorf("invalid DISPLAY %q", display)
+	}
+	host, num := display[:i], display[i+1:]
+	d := &x11Display{}
+	if n, s, ok := strings.Cut(num, "."); ok {
+		num = n
+		d.screen, _ = strconv.Atoi(s)
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid DISPLAY %q", display)
+	}
+	d.number = n
+	switch {
+	case strings.HasPrefix(host, "/"):
+		d.network, d.addr = "unix", host
+	case host == "" || host == "unix":
+		d.network, d.addr = "unix", filepath.Join("/tmp/.X11-unix", "X"+num)
+	default:
+		d.network, d.addr = "tcp", net.JoinHostPort(host, strconv.Itoa(6000+n))
+	}
+	return d, nil
+}
+
+// x11Forwarder serves the x11 channels of one client. The server is given a
+// random fake cookie; each connection's fake cookie is checked and replaced
+// by the local display's real one, as OpenSSH does.
+type x11Forwarder struct {
+	display  *x11Display
+	fake     []byte
+	realName string
+	realData []byte
+
+	mu       sync.Mutex
+	sessions int
+	conns    map[net.Conn]struct{}
+}
+
+// localXauth returns the real cookie for display from xauth(1), if any.
+func localXauth(display string) (string, []byte) {
+	out, err := exec.Command("xauth", "list", display).Output()
+	if err != nil {
+		return "", nil
+	}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		f := strings.Fields(sc.Text())
+		if len(f) == 3 && f[1] == x11AuthProto {
+			if data, err := hex.DecodeString(f[2]); err == nil {
+				return f[1], data
+			}
+		}
+	}
+	return "", nil
+}
+
+// x11Forwarder returns the forwarder of the current client, registering the
+// x11 channel handler on first use.
+func (s *Sshobject) x11Forwarder() (*x11Forwarder, error) {
+	ref := &s.x11
+	if sc := s.shared; sc != nil {
+		sc.mu.Lock()
+		defer sc.mu.Unlock()
+		ref = &sc.x11
+	}
+	if *ref != nil {
+		return *ref, nil
+	}
+	display := X11Display()
+	d, err := parseDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+	chans := s.client.HandleChannelOpen("x11")
+	if chans == nil {
+		return nil, fmt.Errorf("x11 channel handler already registered")
+	}
+	f := &x11Forwarder{display: d, fake: make([]byte, 16), conns: make(map[net.Conn]struct{})}
+	if _, err := rand.Read(f.fake); err != nil {
+		return nil, err
+	}
+	f.realName, f.realData = localXauth(display)
+	go func() {
+		for nc := range chans {
+			go f.serve(nc)
+		}
+		f.closeConns()
+	}()
+	*ref = f
+	return f, nil
+}
+
+// requestX11Forwarding sends x11-req on sess and ties the forwarder's
+// connections to the session's lifetime.
+func (s *Sshobject) requestX11Forwarding(sess *ssh.Session) (*x11Forwarder, error) {
+	f, err := s.x11Forwarder()
+	if err != nil {
+		return nil, fmt.Errorf("x11 forwarding failed: %v", err)
+	}
+	req := struct {
+		SingleConnection bool
+		AuthProtocol     string
+		AuthCookie       string
+		ScreenNumber     uint32
+	}{false, x11AuthProto, hex.EncodeToString(f.fake), uint32(f.display.screen)}
+	f.mu.Lock()
+	f.sessions++
+	f.mu.Unlock()
+	ok, err := sess.SendRequest("x11-req", true, ssh.Marshal(&req))
+	if err == nil && !ok {
+		err = fmt.Errorf("rejected by server")
+	}
+	if err != nil {
+		f.sessionEnded()
+		return nil, fmt.Errorf("x11 forwarding request failed: %v", err)
+	}
+	return f, nil
+}
+
+// sessionEnded closes the X11 connections once no session uses them.
+func (f *x11Forwarder) sessionEnded() {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	f.sessions--
+	last := f.sessions <= 0
+	f.mu.Unlock()
+	if last {
+		f.closeConns()
+	}
+}
+
+func (f *x11Forwarder) closeConns() {
+	f.mu.Lock()
+	conns := f.conns
+	f.conns = make(map[net.Conn]struct{})
+	f.mu.Unlock()
+	for c := range conns {
+		_ = c.Close()
+	}
+}
+
+func (f *x11Forwarder) serve(nc ssh.NewChannel) {
+	f.mu.Lock()
+	active := f.sessions > 0
+	f.mu.Unlock()
+	if !active {
+		_ = nc.Reject(ssh.Prohibited, "x11 forwarding not active")
+		return
+	}
+	local, err := net.DialTimeout(f.display.network, f.display.addr, 5*time.Second)
+	if err != nil {
+		LogErrorf("X11 display %s unreachable: %v", f.display.addr, err)
+		_ = nc.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	ch, reqs, err := nc.Accept()
+	if err != nil {
+		_ = local.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	if err := f.relaySetup(ch, local); err != nil {
+		LogErrorf("X11 connection refused: %v", err)
+		_ = ch.Close()
+		_ = local.Close()
+		return
+	}
+	f.mu.Lock()
+	f.conns[local] = struct{}{}
+	f.mu.Unlock()
+
+	done := make(chan struct{}, 2)
+	go func() { _, _ = io.Copy(local, ch); done <- struct{}{} }()
+	go func() { _, _ = io.Copy(ch, local); _ = ch.CloseWrite(); done <- struct{}{} }()
+	<-done
+	_ = ch.Close()
+	_ = local.Close()
+	f.mu.Lock()
+	delete(f.conns, local)
+	f.mu.Unlock()
+}
+
+// relaySetup reads the X11 connection setup from the remote client, checks
+// the fake cookie and writes the setup to the display with the real one.
+func (f *x11Forwarder) relaySetup(ch io.Reader, local io.Writer) error {
+	hdr := make([]byte, 12)
+	if _, err := io.ReadFull(ch, hdr); err != nil {
+		return err
+	}
+	var order binary.ByteOrder
+	switch hdr[0] {
+	case 'B':
+		order = binary.BigEndian
+	case 'l':
+		order = binary.LittleEndian
+	default:
+		return fmt.Errorf("bad byte order %#x", hdr[0])
+	}
+	nameLen, dataLen := int(order.Uint16(hdr[6:])), int(order.Uint16(hdr[8:]))
+	body := make([]byte, pad4(nameLen)+pad4(dataLen))
+	if _, err := io.ReadFull(ch, body); err != nil {
+		return err
+	}
+	name := string(body[:nameLen])
+	data := body[pad4(nameLen) : pad4(nameLen)+dataLen]
+	if name != x11AuthProto || !bytes.Equal(data, f.fake) {
+		return fmt.Errorf("authentication cookie mismatch")
+	}
+
+	realName, realData := f.realName, f.realData
+	order.PutUint16(hdr[6:], uint16(len(realName)))
+	order.PutUint16(hdr[8:], uint16(len(realData)))
+	out := make([]byte, 0, 12+pad4(len(realName))+pad4(len(realData)))
+	out = append(out, hdr...)
+	out = append(out, realName...)
+	out = append(out, make([]byte, pad4(len(realName))-len(realName))...)
+	out = append(out, realData...)
+	out = append(out, make([]byte, pad4(len(realData))-len(realData))...)
+	_, err := local.Write(out)
+	return err
+}
+
+func pad4(n int) int { return (n + 3) &^ 3 }
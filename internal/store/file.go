@@ -0,0 +1,57 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// AppDir returns the Erban directory under the user config dir, creating it
+// if needed. Falls back to the working directory when no config dir exists.
+func AppDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil || dir == "" {
+		dir = "."
+	}
+	appdir := filepath.Join(dir, "Erban")
+	_ = os.MkdirAll(appdir, 0o755)
+	return appdir
+}
+
+// WriteFileAtomic writes data to a temp file next to path and renames it into
+// place, so readers see either the old or the new content, never a torn file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	name := tmp.Name()
+	cleanup := func() {
+		_ = tmp.Close()
+		_ = os.Remove(name)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		cleanup()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		cleanup()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(name)
+		return err
+	}
+	if err := os.Chmod(name, perm); err != nil {
+		_ = os.Remove(name)
+		return err
+	}
+	if err := os.Rename(name, path); err != nil {
+		_ = os.Remove(name)
+		return err
+	}
+	return nil
+}
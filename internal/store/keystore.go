@@ -0,0 +1,454 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// KeystoreSchemaVersion is the on-disk version of an encrypted keys.json.
+const KeystoreSchemaVersion = 1
+
+// DefaultAutoLock is how long an unlocked keystore stays open without use.
+const DefaultAutoLock = 15 * time.Minute
+
+var (
+	// ErrKeystoreLocked is returned by entry operations while the store is locked.
+	ErrKeystoreLocked = errors.New("keystore is locked")
+	// ErrBadPassphrase is returned when the master passphrase does not match.
+	ErrBadPassphrase = errors.New("incorrect master passphrase")
+	// ErrKeyNotFound is returned when no entry has the requested name.
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+// keystoreCheck is sealed with the master key so a wrong passphrase is
+// detected on unlock rather than on the first entry read.
+var keystoreCheck = []byte("erban-keystore")
+
+// kdfParams records how the master key was derived from the passphrase.
+type kdfParams struct {
+	Name    string `json:"name"`
+	Salt    []byte `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // KiB
+	Threads uint8  `json:"threads"`
+}
+
+// keystoreDoc is the on-disk layout of an encrypted keys.json. Every sealed
+// value is nonce||ciphertext from AES-256-GCM, with the entry name as
+// additional data so entries cannot be swapped between names.
+type keystoreDoc struct {
+	Version int               `json:"version"`
+	KDF     kdfParams         `json:"kdf"`
+	Check   []byte            `json:"check"`
+	Keys    map[string][]byte `json:"keys"`
+}
+
+// KeystoreState describes the keystore for the UI.
+type KeystoreState struct {
+	// Encrypted is false for a new or legacy plaintext store; the first
+	// unlock then sets the master passphrase.
+	Encrypted bool `json:"encrypted"`
+	Locked    bool `json:"locked"`
+	Count     int  `json:"count"`
+}
+
+// KeyStore holds private keys encrypted at rest under a master passphrase.
+// Entries can only be read or written while the store is unlocked; it locks
+// itself again after AutoLock of inactivity.
+type KeyStore struct {
+	mu     sync.Mutex
+	path   string
+	doc    keystoreDoc
+	legacy map[string]string
+	loaded bool
+
+	key      []byte
+	autoLock time.Duration
+	timer    *time.Timer
+
+	// OnLock, when set, is called after the store locks itself on timeout.
+	OnLock func()
+}
+
+// NewKeyStore returns a store backed by path; empty means keys.json in AppDir.
+func NewKeyStore(path string) *KeyStore {
+	if strings.TrimSpace(path) == "" {
+		path = filepath.Join(AppDir(), "keys.json")
+	}
+	return &KeyStore{path: path, autoLock: DefaultAutoLock}
+}
+
+// Path returns the backing file.
+func (s *KeyStore) Path() string { return s.path }
+
+func (s *KeyStore) loadLocked() error {
+	if s.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(strings.TrimSpace(string(data))) == 0) {
+		s.legacy = map[string]string{}
+		s.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var head map[string]json.RawMessage
+	if err := json.Unmarshal(data, &head); err != nil {
+		return fmt.Errorf("load %s: %w", s.path, err)
+	}
+	if _, ok := head["kdf"]; !ok {
+		// Early builds stored filename -> base64 PEM in the clear.
+		legacy := map[string]string{}
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return fmt.Errorf("load %s: %w", s.path, err)
+		}
+		s.legacy = legacy
+		s.loaded = true
+		return nil
+	}
+	var doc keystoreDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("load %s: %w", s.path, err)
+	}
+	if doc.Version > KeystoreSchemaVersion {
+		return fmt.Errorf("keystore schema v%d is newer than supported v%d", doc.Version, KeystoreSchemaVersion)
+	}
+	if doc.Keys == nil {
+		doc.Keys = map[string][]byte{}
+	}
+	s.doc = doc
+	s.legacy = nil
+	s.loaded = true
+	return nil
+}
+
+func (s *KeyStore) saveLocked() error {
+	s.doc.Version = KeystoreSchemaVersion
+	data, err := json.MarshalIndent(s.doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(s.path, data, 0o600)
+}
+
+// State reports whether the store is encrypted and unlocked.
+func (s *KeyStore) State() (KeystoreState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return KeystoreState{}, err
+	}
+	if s.legacy != nil {
+		return KeystoreState{Encrypted: false, Locked: true, Count: len(s.legacy)}, nil
+	}
+	return KeystoreState{Encrypted: true, Locked: s.key == nil, Count: len(s.doc.Keys)}, nil
+}
+
+// Unlock derives the master key from passphrase and opens the store. A new
+// or plaintext store is encrypted under passphrase and rewritten.
+func (s *KeyStore) Unlock(passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("empty master passphrase")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	if s.legacy != nil {
+		return s.migrateLocked(passphrase)
+	}
+	key := deriveKey(passphrase, s.doc.KDF)
+	if err := verifyCheck(key, s.doc.Check); err != nil {
+		return err
+	}
+	s.setKeyLocked(key)
+	return nil
+}
+
+// migrateLocked seals every plaintext entry under a fresh master key.
+func (s *KeyStore) migrateLocked(passphrase string) error {
+	doc, key, err := newKeystoreDoc(passphrase)
+	if err != nil {
+		return err
+	}
+	for name, v := range s.legacy {
+		sealed, err := seal(key, name, []byte(v))
+		if err != nil {
+			return err
+		}
+		doc.Keys[name] = sealed
+	}
+	prev := s.doc
+	s.doc = doc
+	if err := s.saveLocked(); err != nil {
+		s.doc = prev
+		return err
+	}
+	s.legacy = nil
+	s.setKeyLocked(key)
+	return nil
+}
+
+// Lock forgets the master key.
+func (s *KeyStore) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lockLocked()
+}
+
+func (s *KeyStore) lockLocked() {
+	for i := range s.key {
+		s.key[i] = 0
+	}
+	s.key = nil
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// SetAutoLock changes the inactivity timeout; zero disables auto-lock.
+func (s *KeyStore) SetAutoLock(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d < 0 {
+		d = 0
+	}
+	s.autoLock = d
+	if s.key != nil {
+		s.touchLocked()
+	}
+}
+
+func (s *KeyStore) setKeyLocked(key []byte) {
+	s.lockLocked()
+	s.key = key
+	s.touchLocked()
+}
+
+// touchLocked restarts the auto-lock timer.
+func (s *KeyStore) touchLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.autoLock <= 0 {
+		return
+	}
+	var t *time.Timer
+	t = time.AfterFunc(s.autoLock, func() {
+		s.mu.Lock()
+		if s.timer != t {
+			s.mu.Unlock()
+			return
+		}
+		s.lockLocked()
+		cb := s.OnLock
+		s.mu.Unlock()
+		if cb != nil {
+			cb()
+		}
+	})
+	s.timer = t
+}
+
+// ChangePassphrase re-encrypts every entry under newPass. It works whether
+// or not the store is unlocked and leaves it unlocked with the new key.
+func (s *KeyStore) ChangePassphrase(oldPass, newPass string) error {
+	if newPass == "" {
+		return fmt.Errorf("empty master passphrase")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	if s.legacy != nil {
+		return s.migrateLocked(newPass)
+	}
+	oldKey := deriveKey(oldPass, s.doc.KDF)
+	if err := verifyCheck(oldKey, s.doc.Check); err != nil {
+		return err
+	}
+	doc, key, err := newKeystoreDoc(newPass)
+	if err != nil {
+		return err
+	}
+	for name, sealed := range s.doc.Keys {
+		plain, err := open(oldKey, name, sealed)
+		if err != nil {
+			return fmt.Errorf("decrypt %s: %w", name, err)
+		}
+		if doc.Keys[name], err = seal(key, name, plain); err != nil {
+			return err
+		}
+	}
+	prev := s.doc
+	s.doc = doc
+	if err := s.saveLocked(); err != nil {
+		s.doc = prev
+		return err
+	}
+	s.setKeyLocked(key)
+	return nil
+}
+
+// unlockedLocked loads the store and fails unless it is unlocked.
+func (s *KeyStore) unlockedLocked() error {
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	if s.key == nil {
+		return ErrKeystoreLocked
+	}
+	s.touchLocked()
+	return nil
+}
+
+// Names returns the stored entry names in order.
+func (s *KeyStore) Names() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.unlockedLocked(); err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(s.doc.Keys))
+	for name := range s.doc.Keys {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// Get returns the decrypted entry stored under name.
+func (s *KeyStore) Get(name string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.unlockedLocked(); err != nil {
+		return nil, err
+	}
+	sealed, ok := s.doc.Keys[name]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return open(s.key, name, sealed)
+}
+
+// Put encrypts and stores data under name, replacing any existing entry.
+func (s *KeyStore) Put(name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.unlockedLocked(); err != nil {
+		return err
+	}
+	sealed, err := seal(s.key, name, data)
+	if err != nil {
+		return err
+	}
+	prev, had := s.doc.Keys[name]
+	s.doc.Keys[name] = sealed
+	if err := s.saveLocked(); err != nil {
+		if had {
+			s.doc.Keys[name] = prev
+		} else {
+			delete(s.doc.Keys, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes the entry stored under name.
+func (s *KeyStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.unlockedLocked(); err != nil {
+		return err
+	}
+	prev, ok := s.doc.Keys[name]
+	if !ok {
+		return nil
+	}
+	delete(s.doc.Keys, name)
+	if err := s.saveLocked(); err != nil {
+		s.doc.Keys[name] = prev
+		return err
+	}
+	return nil
+}
+
+// newKeystoreDoc returns an empty document and master key for passphrase.
+func newKeystoreDoc(passphrase string) (keystoreDoc, []byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return keystoreDoc{}, nil, err
+	}
+	kdf := kdfParams{Name: "argon2id", Salt: salt, Time: 3, Memory: 64 * 1024, Threads: 4}
+	key := deriveKey(passphrase, kdf)
+	check, err := seal(key, "", keystoreCheck)
+	if err != nil {
+		return keystoreDoc{}, nil, err
+	}
+	doc := keystoreDoc{Version: KeystoreSchemaVersion, KDF: kdf, Check: check, Keys: map[string][]byte{}}
+	return doc, key, nil
+}
+
+func deriveKey(passphrase string, p kdfParams) []byte {
+	return argon2.IDKey([]byte(passphrase), p.Salt, p.Time, p.Memory, p.Threads, 32)
+}
+
+func verifyCheck(key, check []byte) error {
+	plain, err := open(key, "", check)
+	if err != nil || subtle.ConstantTimeCompare(plain, keystoreCheck) != 1 {
+		return ErrBadPassphrase
+	}
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(key []byte, name string, plain []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, []byte(name)), nil
+}
+
+func open(key []byte, name string, sealed []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("sealed value too short")
+	}
+	n := aead.NonceSize()
+	return aead.Open(nil, sealed[:n], sealed[n:], []byte(name))
+}
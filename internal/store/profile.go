@@ -0,0 +1,334 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProfileSchemaVersion is the on-disk version written by this build.
+const ProfileSchemaVersion = 1
+
+// ErrProfileNotFound is returned when no profile has the requested id.
+var ErrProfileNotFound = errors.New("profile not found")
+
+// Auth methods understood by a Profile.
+const (
+	AuthPassword = "password"
+	AuthKey      = "key"
+	AuthAgent    = "agent"
+)
+
+// JumpHost is one ProxyJump hop of a Profile.
+type JumpHost struct {
+	Host   string `json:"host"`
+	Port   int    `json:"port,omitempty"`
+	User   string `json:"user"`
+	Auth   string `json:"auth"`
+	KeyRef string `json:"keyRef,omitempty"`
+	// CertRef names the keystore entry with a user certificate for KeyRef.
+	CertRef string `json:"certRef,omitempty"`
+}
+
+// Forward is a port forward started by default after connecting.
+// Mode is local, remote, dynamic or http; To is empty for dynamic and http.
+// local-unix and remote-unix forward to and from a unix socket on the
+// server; the local end is a TCP address or a socket path.
+type Forward struct {
+	Mode string `json:"mode"`
+	From string `json:"from"`
+	To   string `json:"to,omitempty"`
+
+	// Username turns on proxy username/password auth for a dynamic or
+	// http forward; the password is the keystore entry PasswordRef. UDPHelper
+	// is the remote command relaying UDP ASSOCIATE datagrams.
+	Username    string `json:"username,omitempty"`
+	PasswordRef string `json:"passwordRef,omitempty"`
+	UDPHelper   string `json:"udpHelper,omitempty"`
+}
+
+// Profile is a saved SSH connection. Passwords are never stored; KeyRef names
+// an entry in the keystore.
+type Profile struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Host      string     `json:"host"`
+	Port      int        `json:"port,omitempty"`
+	User      string     `json:"user"`
+	Auth      string     `json:"auth"`
+	KeyRef    string     `json:"keyRef,omitempty"`
+	ProxyURL  string     `json:"proxyUrl,omitempty"`
+	Jumps     []JumpHost `json:"jumps,omitempty"`
+	Forwards  []Forward  `json:"forwards,omitempty"`
+	Label     string     `json:"label,omitempty"`
+	Groups    []string   `json:"groups,omitempty"`
+	Rows      int        `json:"rows,omitempty"`
+	Cols      int        `json:"cols,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+
+	// CertRef names the keystore entry with a user certificate for KeyRef.
+	CertRef string `json:"certRef,omitempty"`
+	// ForwardAgent forwards the local ssh-agent to the interactive session.
+	ForwardAgent bool `json:"forwardAgent,omitempty"`
+	// ForwardX11 forwards X11 connections to the local display.
+	ForwardX11 bool `json:"forwardX11,omitempty"`
+	// ReconnectAttempts limits automatic reconnects; 0 uses the default
+	// and a negative value disables them.
+	ReconnectAttempts int `json:"reconnectAttempts,omitempty"`
+	// KeepaliveInterval is in seconds; 0 uses the default and a negative
+	// value disables keepalives. KeepaliveCountMax unanswered probes drop
+	// the connection.
+	KeepaliveInterval int `json:"keepaliveInterval,omitempty"`
+	KeepaliveCountMax int `json:"keepaliveCountMax,omitempty"`
+	// Multiplex shares one connection between all sessions opened from
+	// this profile, so it authenticates once.
+	Multiplex bool `json:"multiplex,omitempty"`
+
+	// Term, TermModes and Env configure the PTY; empty uses the defaults.
+	Term      string            `json:"term,omitempty"`
+	TermModes map[string]uint32 `json:"termModes,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	// StartupCommand runs instead of the login shell, e.g. attaching tmux;
+	// Subsystem starts a subsystem instead and takes precedence.
+	StartupCommand string `json:"startupCommand,omitempty"`
+	Subsystem      string `json:"subsystem,omitempty"`
+}
+
+// Addr returns host:port, defaulting the port to 22.
+func (p *Profile) Addr() string {
+	return hostAddr(p.Host, p.Port)
+}
+
+// Addr returns host:port, defaulting the port to 22.
+func (j *JumpHost) Addr() string {
+	return hostAddr(j.Host, j.Port)
+}
+
+func hostAddr(host string, port int) string {
+	if port == 0 {
+		port = 22
+	}
+	if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+		host = "[" + host + "]"
+	}
+	return fmt.Sprintf("%s:%d", host, port)
+}
+
+// profileDoc is the on-disk layout of profiles.json.
+type profileDoc struct {
+	Version  int       `json:"version"`
+	Profiles []Profile `json:"profiles"`
+}
+
+// profileMigrations upgrade a raw document from version i+1 to i+2. Version
+// 1 is the first format; a document without a version is read as 1.
+var profileMigrations []func(raw []byte) ([]byte, error)
+
+// ProfileStore persists connection profiles as versioned JSON.
+type ProfileStore struct {
+	mu     sync.Mutex
+	path   string
+	doc    profileDoc
+	loaded bool
+}
+
+// NewProfileStore returns a store backed by path; empty means
+// profiles.json in AppDir.
+func NewProfileStore(path string) *ProfileStore {
+	if strings.TrimSpace(path) == "" {
+		path = filepath.Join(AppDir(), "profiles.json")
+	}
+	return &ProfileStore{path: path}
+}
+
+// Path returns the backing file.
+func (s *ProfileStore) Path() string { return s.path }
+
+func (s *ProfileStore) loadLocked() error {
+	if s.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(strings.TrimSpace(string(data))) == 0) {
+		s.doc = profileDoc{Version: ProfileSchemaVersion}
+		s.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	doc, migrated, err := migrateProfiles(data)
+	if err != nil {
+		return fmt.Errorf("load %s: %w", s.path, err)
+	}
+	s.doc = doc
+	s.loaded = true
+	if migrated {
+		return s.saveLocked()
+	}
+	return nil
+}
+
+// migrateProfiles decodes data, applying migrations up to the current version.
+func migrateProfiles(data []byte) (profileDoc, bool, error) {
+	var head struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return profileDoc{}, false, err
+	}
+	version := max(head.Version, 1)
+	if version > ProfileSchemaVersion {
+		return profileDoc{}, false, fmt.Errorf("profile schema v%d is newer than supported v%d", version, ProfileSchemaVersion)
+	}
+	migrated := version < ProfileSchemaVersion
+	for ; version < ProfileSchemaVersion; version++ {
+		next, err := profileMigrations[version-1](data)
+		if err != nil {
+			return profileDoc{}, false, fmt.Errorf("migrate profiles v%d: %w", version, err)
+		}
+		data = next
+	}
+	var doc profileDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return profileDoc{}, false, err
+	}
+	doc.Version = ProfileSchemaVersion
+	return doc, migrated, nil
+}
+
+func (s *ProfileStore) saveLocked() error {
+	s.doc.Version = ProfileSchemaVersion
+	data, err := json.MarshalIndent(s.doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(s.path, data, 0o600)
+}
+
+// List returns all profiles ordered by label, then name.
+func (s *ProfileStore) List() ([]Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	out := append([]Profile(nil), s.doc.Profiles...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Label != out[j].Label {
+			return out[i].Label < out[j].Label
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// Get returns the profile with id.
+func (s *ProfileStore) Get(id string) (Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return Profile{}, err
+	}
+	for _, p := range s.doc.Profiles {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return Profile{}, ErrProfileNotFound
+}
+
+// Save creates p when its ID is empty, otherwise replaces the stored
+// profile with the same ID. It returns the stored value.
+func (s *ProfileStore) Save(p Profile) (Profile, error) {
+	if strings.TrimSpace(p.Host) == "" {
+		return Profile{}, fmt.Errorf("profile host is required")
+	}
+	switch p.Auth {
+	case "":
+		p.Auth = AuthPassword
+	case AuthPassword, AuthKey, AuthAgent:
+	default:
+		return Profile{}, fmt.Errorf("unsupported auth method: %s", p.Auth)
+	}
+	if p.Auth == AuthKey && p.KeyRef == "" {
+		return Profile{}, fmt.Errorf("key auth requires a key reference")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return Profile{}, err
+	}
+
+	prev := append([]Profile(nil), s.doc.Profiles...)
+	now := time.Now().UTC()
+	p.UpdatedAt = now
+	if p.ID == "" {
+		id, err := newProfileID()
+		if err != nil {
+			return Profile{}, err
+		}
+		p.ID = id
+		p.CreatedAt = now
+		s.doc.Profiles = append(s.doc.Profiles, p)
+	} else {
+		idx := s.indexLocked(p.ID)
+		if idx < 0 {
+			return Profile{}, ErrProfileNotFound
+		}
+		p.CreatedAt = s.doc.Profiles[idx].CreatedAt
+		s.doc.Profiles[idx] = p
+	}
+	if err := s.saveLocked(); err != nil {
+		s.doc.Profiles = prev
+		return Profile{}, err
+	}
+	return p, nil
+}
+
+// Delete removes the profile with id.
+func (s *ProfileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	idx := s.indexLocked(id)
+	if idx < 0 {
+		return ErrProfileNotFound
+	}
+	prev := append([]Profile(nil), s.doc.Profiles...)
+	s.doc.Profiles = append(s.doc.Profiles[:idx], s.doc.Profiles[idx+1:]...)
+	if err := s.saveLocked(); err != nil {
+		s.doc.Profiles = prev
+		return err
+	}
+	return nil
+}
+
+func (s *ProfileStore) indexLocked(id string) int {
+	for i, p := range s.doc.Profiles {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func newProfileID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
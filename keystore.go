@@ -0,0 +1,119 @@
+package main
+
+import (
+	"time"
+
+	"github.com/flyingeirc/erban/internal/store"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// KeystoreStateResult 表示密钥库状态的返回数据
+type KeystoreStateResult struct {
+	State *store.KeystoreState `json:"state,omitempty"`
+	Error string               `json:"error,omitempty"`
+}
+
+// KeyListResult 表示密钥库条目列表的返回数据
+type KeyListResult struct {
+	Names []string `json:"names,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+// keyStore returns the encrypted keystore, emitting "keystore:locked" when
+// it locks itself after inactivity.
+func (b *SSHBridge) keyStore() *store.KeyStore {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.keystore == nil {
+		b.keystore = store.NewKeyStore("")
+		b.keystore.OnLock = func() {
+			if b.ctx != nil {
+				runtime.EventsEmit(b.ctx, "keystore:locked")
+			}
+		}
+	}
+	return b.keystore
+}
+
+// KeystoreState reports whether the keystore is encrypted and unlocked.
+func (b *SSHBridge) KeystoreState() *KeystoreStateResult {
+	st, err := b.keyStore().State()
+	if err != nil {
+		return &KeystoreStateResult{Error: err.Error()}
+	}
+	return &KeystoreStateResult{State: &st}
+}
+
+// UnlockKeystore opens the keystore with the master passphrase. The first
+// unlock of a new or plaintext keystore sets the passphrase and encrypts it.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) UnlockKeystore(passphrase string) string {
+	if err := b.keyStore().Unlock(passphrase); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// LockKeystore forgets the master key until the next UnlockKeystore.
+func (b *SSHBridge) LockKeystore() {
+	b.keyStore().Lock()
+}
+
+// ChangeMasterPassphrase re-encrypts the keystore under newPass.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) ChangeMasterPassphrase(oldPass, newPass string) string {
+	if err := b.keyStore().ChangePassphrase(oldPass, newPass); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// SetKeystoreAutoLock sets the inactivity timeout in seconds; 0 disables it.
+func (b *SSHBridge) SetKeystoreAutoLock(seconds int) {
+	b.keyStore().SetAutoLock(time.Duration(seconds) * time.Second)
+}
+
+// KeyList returns the names of stored keys.
+func (b *SSHBridge) KeyList() *KeyListResult {
+	names, err := b.keyStore().Names()
+	if err != nil {
+		return &KeyListResult{Error: err.Error()}
+	}
+	return &KeyListResult{Names: names}
+}
+
+// KeyPut stores/updates a key by filename with its base64-encoded content.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) KeyPut(filename, pemBase64 string) string {
+	if filename == "" || pemBase64 == "" {
+		return "invalid filename or content"
+	}
+	if err := b.keyStore().Put(filename, []byte(pemBase64)); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// KeyGet returns the base64-encoded content by filename (empty if not
+// present or the keystore is locked).
+func (b *SSHBridge) KeyGet(filename string) string {
+	if filename == "" {
+		return ""
+	}
+	val, err := b.keyStore().Get(filename)
+	if err != nil {
+		return ""
+	}
+	return string(val)
+}
+
+// KeyDelete removes a key by filename. Returns empty string on success.
+func (b *SSHBridge) KeyDelete(filename string) string {
+	if filename == "" {
+		return "invalid filename"
+	}
+	if err := b.keyStore().Delete(filename); err != nil {
+		return err.Error()
+	}
+	return ""
+}
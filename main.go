@@ -5,16 +5,16 @@ import (
 	"embed"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
-	"os"
-	"path/filepath"
 	"sync"
 	"time"
 
 	chatmodel "github.com/flyingeirc/erban/internal/chat/model"
 	chatoutput "github.com/flyingeirc/erban/internal/chat/output"
 	sshpkg "github.com/flyingeirc/erban/internal/ssh"
+	"github.com/flyingeirc/erban/internal/store"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -62,12 +62,22 @@ func main() {
 
 // SSHBridge provides Wails-exposed methods that delegate to internal SSH helpers.
 type SSHBridge struct {
-	ctx     context.Context
-	keyFile string
-	keys    map[string]string // filename -> base64 PEM
+	ctx      context.Context
+	keystore *store.KeyStore
 
 	mu       sync.Mutex
 	sessions map[string]*sessionState
+
+	promptSeq int
+	prompts   map[string]chan promptReply
+
+	// execs cancels running ExecStream commands by exec ID.
+	execs map[string]context.CancelFunc
+
+	recordDir string
+	playbacks map[string]context.CancelFunc
+
+	profiles *store.ProfileStore
 }
 
 type sessionState struct {
@@ -76,6 +86,22 @@ type sessionState struct {
 
 	fwdSeq int
 	fwd    map[string]*forwardHandle
+
+	// Terminal size and forwards applied by Connect when the session was
+	// initialized from a saved profile.
+	rows, cols int
+	defaultFwd []store.Forward
+
+	// reconnectCancel aborts an automatic reconnect in progress.
+	reconnectCancel context.CancelFunc
+	// monitor is the metrics interval, restarted after a reconnect; 0 = off.
+	monitor time.Duration
+	// fwdStats stops the periodic "ssh:fwdstats" events.
+	fwdStats context.CancelFunc
+	// rec records the shell, carried over to the new stream on reconnect.
+	rec *sshpkg.Recorder
+	// scrollback keeps recent output across reconnects and UI reloads.
+	scrollback *sshpkg.Scrollback
 }
 
 // SFTPListResult 表示 SFTP 目录列表操作的返回数据
@@ -84,6 +110,18 @@ type SFTPListResult struct {
 	Error   string             `json:"error,omitempty"`
 }
 
+// SSHConfigResult 表示从 ~/.ssh/config 导入的主机列表
+type SSHConfigResult struct {
+	Hosts []sshpkg.HostProfile `json:"hosts,omitempty"`
+	Error string               `json:"error,omitempty"`
+}
+
+// SessionInfoResult 表示会话连接信息的返回数据
+type SessionInfoResult struct {
+	Info  *sshpkg.SessionInfo `json:"info,omitempty"`
+	Error string              `json:"error,omitempty"`
+}
+
 // SFTPDownloadResult 表示 SFTP 下载操作的返回数据
 type SFTPDownloadResult struct {
 	Data  []byte `json:"data,omitempty"`
@@ -132,19 +170,49 @@ func (b *SSHBridge) requireSessionObject(sessionID string) (*sshpkg.Sshobject, e
 	return obj, nil
 }
 
+// updateSessionObject 在持有 b.mu 时修改会话的 SSH 对象，避免与 watchSession 和重连竞争
+func (b *SSHBridge) updateSessionObject(sessionID string, fn func(obj *sshpkg.Sshobject) error) string {
+	if sessionID == "" {
+		return "invalid session id"
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sess := b.getSessionLocked(sessionID)
+	if sess == nil || sess.obj == nil {
+		return "ssh object not initialized"
+	}
+	if err := fn(sess.obj); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
 func (b *SSHBridge) closeSessionLocked(id string, sess *sessionState, remove bool) {
 	if sess == nil {
 		return
 	}
+	if sess.reconnectCancel != nil {
+		sess.reconnectCancel()
+		sess.reconnectCancel = nil
+	}
 	_ = stopForwardsLocked(sess)
+	stopRecordingLocked(sess)
 	if sess.ses != nil {
 		_ = sess.ses.Close()
 		sess.ses = nil
 	}
 	if sess.obj != nil {
+		b.endChannels(id, sess.obj)
 		sshpkg.Close(sess.obj)
 		sess.obj = nil
 	}
+	sess.rows, sess.cols = 0, 0
+	sess.defaultFwd = nil
+	sess.monitor = 0
+	if sess.fwdStats != nil {
+		sess.fwdStats()
+		sess.fwdStats = nil
+	}
 	if remove && b.sessions != nil && id != "" {
 		delete(b.sessions, id)
 	}
@@ -178,169 +246,249 @@ func (b *SSHBridge) InitWithPasswd(sessionID, host, user, passwd string) {
 	sess := b.ensureSessionLocked(sessionID)
 	b.closeSessionLocked(sessionID, sess, false)
 	sess.obj = sshpkg.InitWithPasswd(host, user, passwd)
+	b.attachPrompts(sessionID, sess.obj)
 }
 
-// InitWithPem initializes an SSH object with private key auth using base64 pem data.
-// The third argument should be a base64-encoded PEM key content.
-func (b *SSHBridge) InitWithPem(sessionID, host, user, pemBase64 string) {
+// InitWithPem initializes an SSH object with private key auth using base64 key data.
+// The key may be PEM, OpenSSH or PuTTY (.ppk) format; encrypted keys ask for
+// their passphrase on "ssh:passphrase:<sessionID>".
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) InitWithPem(sessionID, host, user, pemBase64 string) string {
 	if sessionID == "" {
-		return
+		return "invalid session id"
 	}
 	data, err := base64.StdEncoding.DecodeString(pemBase64)
 	if err != nil {
-		return
+		return err.Error()
 	}
+	obj, err := sshpkg.InitWithPem(host, user, data, b.passphrasePrompt(sessionID))
+	if err != nil {
+		return err.Error()
+	}
+	b.attachPrompts(sessionID, obj)
 
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	sess := b.ensureSessionLocked(sessionID)
 	b.closeSessionLocked(sessionID, sess, false)
-	sess.obj = sshpkg.InitWithPem(host, user, data)
+	sess.obj = obj
+	return ""
 }
 
-// ---- Simple keystore persisted to JSON ----
-func (b *SSHBridge) ensureKeyStorePath() string {
-	if b.keyFile != "" {
-		return b.keyFile
+// InitWithAgent initializes an SSH object that authenticates with the keys
+// held by the local ssh-agent (SSH_AUTH_SOCK).
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) InitWithAgent(sessionID, host, user string) string {
+	if sessionID == "" {
+		return "invalid session id"
 	}
-	dir, err := os.UserConfigDir()
-	if err != nil || dir == "" {
-		dir = "."
+	obj, err := sshpkg.InitWithAgent(host, user)
+	if err != nil {
+		return err.Error()
 	}
-	appdir := filepath.Join(dir, "Erban")
-	_ = os.MkdirAll(appdir, 0o755)
-	b.keyFile = filepath.Join(appdir, "keys.json")
-	return b.keyFile
+	b.attachPrompts(sessionID, obj)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sess := b.ensureSessionLocked(sessionID)
+	b.closeSessionLocked(sessionID, sess, false)
+	sess.obj = obj
+	return ""
 }
 
-func (b *SSHBridge) loadKeys() {
-	var path string
-	if b.keys != nil {
-		return
+// SetCertificate attaches a base64-encoded OpenSSH user certificate
+// (id_*-cert.pub) to the session's private key.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) SetCertificate(sessionID, certBase64 string) string {
+	data, err := base64.StdEncoding.DecodeString(certBase64)
+	if err != nil {
+		return err.Error()
 	}
-	b.keys = map[string]string{}
+	return b.updateSessionObject(sessionID, func(obj *sshpkg.Sshobject) error {
+		return sshpkg.SetCertificate(obj, data)
+	})
+}
 
-	if b.keyFile == "" {
-		path = b.ensureKeyStorePath()
-	} else {
-		path = b.keyFile
-	}
+// SetPassword sets the password offered after publickey auth when the server
+// requires both, and answered to keyboard-interactive password prompts.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) SetPassword(sessionID, passwd string) string {
+	return b.updateSessionObject(sessionID, func(obj *sshpkg.Sshobject) error {
+		return sshpkg.SetPassword(obj, passwd)
+	})
+}
 
-	data, err := os.ReadFile(path)
-	if err != nil || len(data) == 0 {
-		return
+// SetAgentForwarding toggles ssh-agent forwarding for the session's next
+// interactive shell. Returns empty string on success; otherwise error text.
+func (b *SSHBridge) SetAgentForwarding(sessionID string, enabled bool) string {
+	obj, err := b.requireSessionObject(sessionID)
+	if err != nil {
+		return err.Error()
+	}
+	if err := sshpkg.SetAgentForwarding(obj, enabled); err != nil {
+		return err.Error()
 	}
-	_ = json.Unmarshal(data, &b.keys)
+	return ""
 }
 
-func (b *SSHBridge) saveKeys() error {
-	if b.keys == nil {
-		b.keys = map[string]string{}
+// SetX11Forwarding toggles X11 forwarding to the local DISPLAY for shells
+// started after the call.
+func (b *SSHBridge) SetX11Forwarding(sessionID string, enabled bool) string {
+	obj, err := b.requireSessionObject(sessionID)
+	if err != nil {
+		return err.Error()
+	}
+	if err := sshpkg.SetX11Forwarding(obj, enabled); err != nil {
+		return err.Error()
 	}
+	return ""
+}
 
-	data, err := json.MarshalIndent(b.keys, "", "  ")
+// SessionInfo returns connection details for the session.
+func (b *SSHBridge) SessionInfo(sessionID string) *SessionInfoResult {
+	obj, err := b.requireSessionObject(sessionID)
 	if err != nil {
-		return err
+		return &SessionInfoResult{Error: err.Error()}
 	}
-	return os.WriteFile(b.keyFile, data, 0o600)
+	info := sshpkg.Info(obj)
+	return &SessionInfoResult{Info: &info}
 }
 
-// ----- Port forwarding (local/remote/dynamic) -----
-
-type forwardHandle struct {
-	id     string
-	mode   string // local | remote | dynamic
-	from   string
-	to     string // target or ""
-	cancel func() error
+// SSHConfigHosts parses an OpenSSH client config (empty path = ~/.ssh/config)
+// and returns every concrete Host with wildcard blocks and Includes applied.
+func (b *SSHBridge) SSHConfigHosts(path string) *SSHConfigResult {
+	cfg, err := sshpkg.ParseSSHConfig(path)
+	if err != nil {
+		sshpkg.LogErrorf("SSH config import failed: %v", err)
+		return &SSHConfigResult{Error: err.Error()}
+	}
+	return &SSHConfigResult{Hosts: cfg.Profiles()}
 }
 
-// StartLocalForward starts a local forward: localAddr => remoteAddr via SSH.
+// InitFromSSHConfig initializes the session from a Host alias in an OpenSSH
+// client config, including its ProxyJump chain. passwd is used for nodes
+// without a usable IdentityFile. Forwards listed in the profile are not
+// started; use the Start*Forward methods after Connect.
 // Returns empty string on success; otherwise error text.
-func (b *SSHBridge) StartLocalForward(sessionID, localAddr, remoteAddr string) string {
+func (b *SSHBridge) InitFromSSHConfig(sessionID, path, alias, passwd string) string {
 	if sessionID == "" {
 		return "invalid session id"
 	}
-
-	b.mu.Lock()
-	sess := b.getSessionLocked(sessionID)
-	if sess == nil || sess.obj == nil {
-		b.mu.Unlock()
-		return "ssh object not initialized"
+	cfg, err := sshpkg.ParseSSHConfig(path)
+	if err != nil {
+		return err.Error()
 	}
-	obj := sess.obj
-	b.mu.Unlock()
-
-	cancel, err := obj.LocalForward(localAddr, remoteAddr)
+	obj, err := sshpkg.InitFromProfile(cfg.Resolve(alias), passwd, b.passphrasePrompt(sessionID))
 	if err != nil {
 		return err.Error()
 	}
+	b.attachPrompts(sessionID, obj)
 
 	b.mu.Lock()
-	sess = b.getSessionLocked(sessionID)
-	if sess == nil || sess.obj != obj {
-		b.mu.Unlock()
-		if cancel != nil {
-			_ = cancel()
+	defer b.mu.Unlock()
+
+	sess := b.ensureSessionLocked(sessionID)
+	b.closeSessionLocked(sessionID, sess, false)
+	sess.obj = obj
+	return ""
+}
+
+// attachPrompts wires the session's interactive callbacks into obj and its
+// jump hosts. Keepalive round-trip times are emitted on
+// "ssh:latency:<sessionID>" in milliseconds.
+func (b *SSHBridge) attachPrompts(sessionID string, obj *sshpkg.Sshobject) {
+	obj.HostKeyPrompt = b.hostKeyPrompt(sessionID)
+	obj.KeyboardPrompt = b.keyboardPrompt(sessionID)
+	obj.OnLatency = func(rtt time.Duration) {
+		if b.ctx != nil {
+			runtime.EventsEmit(b.ctx, fmt.Sprintf("ssh:latency:%s", sessionID), float64(rtt.Microseconds())/1000)
 		}
-		return "session not available"
 	}
-	if sess.fwd == nil {
-		sess.fwd = map[string]*forwardHandle{}
+	for _, hop := range obj.Jumps {
+		b.attachPrompts(sessionID, hop)
 	}
-	sess.fwdSeq++
-	id := fmt.Sprintf("lf-%d", sess.fwdSeq)
-	sess.fwd[id] = &forwardHandle{id: id, mode: "local", from: localAddr, to: remoteAddr, cancel: cancel}
-	b.mu.Unlock()
+}
 
-	return ""
+// ----- Port forwarding (local/remote/dynamic) -----
+
+type forwardHandle struct {
+	id     string
+	mode   string // local | remote | dynamic | http | local-unix | remote-unix
+	from   string
+	to     string // target or ""
+	socks  sshpkg.SocksOptions
+	cancel func() error
+	stats  func() sshpkg.ForwardStats
+}
+
+// StartLocalForward starts a local forward: localAddr => remoteAddr via SSH.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) StartLocalForward(sessionID, localAddr, remoteAddr string) string {
+	return b.startForward(sessionID, "local", "lf", localAddr, remoteAddr, sshpkg.SocksOptions{}, func(obj *sshpkg.Sshobject) (*sshpkg.Forwarder, error) {
+		return obj.LocalForward(localAddr, remoteAddr)
+	})
 }
 
 // StartRemoteForward starts a remote forward: remoteBind => localTarget via SSH.
 // Returns empty string on success; otherwise error text.
 func (b *SSHBridge) StartRemoteForward(sessionID, remoteBind, localTarget string) string {
-	if sessionID == "" {
-		return "invalid session id"
-	}
-
-	b.mu.Lock()
-	sess := b.getSessionLocked(sessionID)
-	if sess == nil || sess.obj == nil {
-		b.mu.Unlock()
-		return "ssh object not initialized"
-	}
-	obj := sess.obj
-	b.mu.Unlock()
-
-	cancel, err := obj.RemoteForward(remoteBind, localTarget)
-	if err != nil {
-		return err.Error()
-	}
+	return b.startForward(sessionID, "remote", "rf", remoteBind, localTarget, sshpkg.SocksOptions{}, func(obj *sshpkg.Sshobject) (*sshpkg.Forwarder, error) {
+		return obj.RemoteForward(remoteBind, localTarget)
+	})
+}
 
-	b.mu.Lock()
-	sess = b.getSessionLocked(sessionID)
-	if sess == nil || sess.obj != obj {
-		b.mu.Unlock()
-		if cancel != nil {
-			_ = cancel()
-		}
-		return "session not available"
-	}
-	if sess.fwd == nil {
-		sess.fwd = map[string]*forwardHandle{}
-	}
-	sess.fwdSeq++
-	id := fmt.Sprintf("rf-%d", sess.fwdSeq)
-	sess.fwd[id] = &forwardHandle{id: id, mode: "remote", from: remoteBind, to: localTarget, cancel: cancel}
-	b.mu.Unlock()
+// StartLocalStreamForward forwards local (a TCP address or a unix socket
+// path) to the unix socket remoteSocket on the server, e.g.
+// /var/run/docker.sock.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) StartLocalStreamForward(sessionID, local, remoteSocket string) string {
+	return b.startForward(sessionID, "local-unix", "lu", local, remoteSocket, sshpkg.SocksOptions{}, func(obj *sshpkg.Sshobject) (*sshpkg.Forwarder, error) {
+		return obj.LocalStreamForward(local, remoteSocket)
+	})
+}
 
-	return ""
+// StartRemoteStreamForward listens on the unix socket remoteSocket on the
+// server and forwards connections to local, a TCP address or unix socket
+// path.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) StartRemoteStreamForward(sessionID, remoteSocket, local string) string {
+	return b.startForward(sessionID, "remote-unix", "ru", remoteSocket, local, sshpkg.SocksOptions{}, func(obj *sshpkg.Sshobject) (*sshpkg.Forwarder, error) {
+		return obj.RemoteStreamForward(remoteSocket, local)
+	})
 }
 
 // StartDynamicForward starts a SOCKS5 proxy bound on localSocks that tunnels via SSH.
 // Returns empty string on success; otherwise error text.
 func (b *SSHBridge) StartDynamicForward(sessionID, localSocks string) string {
+	return b.StartDynamicForwardWithOptions(sessionID, localSocks, sshpkg.SocksOptions{})
+}
+
+// StartDynamicForwardWithOptions starts a SOCKS5 proxy that requires
+// opts.Username/opts.Password when a username is set, and relays UDP
+// ASSOCIATE through opts.UDPHelper.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) StartDynamicForwardWithOptions(sessionID, localSocks string, opts sshpkg.SocksOptions) string {
+	return b.startForward(sessionID, "dynamic", "df", localSocks, "", opts, func(obj *sshpkg.Sshobject) (*sshpkg.Forwarder, error) {
+		return obj.DynamicForwardWithOptions(localSocks, opts)
+	})
+}
+
+// StartHTTPProxyForward starts an HTTP proxy (CONNECT and absolute-URI
+// requests) bound on localAddr that tunnels via SSH. The same listener also
+// accepts SOCKS5 clients; opts applies to both protocols.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) StartHTTPProxyForward(sessionID, localAddr string, opts sshpkg.SocksOptions) string {
+	return b.startForward(sessionID, "http", "hf", localAddr, "", opts, func(obj *sshpkg.Sshobject) (*sshpkg.Forwarder, error) {
+		return obj.HTTPProxyForward(localAddr, opts)
+	})
+}
+
+// startForward runs start against the session's ssh object without holding
+// b.mu and records the resulting forward under an id "<prefix>-<n>". socks
+// is kept so the forward can be restarted with the same options.
+func (b *SSHBridge) startForward(sessionID, mode, prefix, from, to string, socks sshpkg.SocksOptions, start func(*sshpkg.Sshobject) (*sshpkg.Forwarder, error)) string {
 	if sessionID == "" {
 		return "invalid session id"
 	}
@@ -354,7 +502,7 @@ func (b *SSHBridge) StartDynamicForward(sessionID, localSocks string) string {
 	obj := sess.obj
 	b.mu.Unlock()
 
-	cancel, err := obj.DynamicForward(localSocks)
+	fw, err := start(obj)
 	if err != nil {
 		return err.Error()
 	}
@@ -363,17 +511,15 @@ func (b *SSHBridge) StartDynamicForward(sessionID, localSocks string) string {
 	sess = b.getSessionLocked(sessionID)
 	if sess == nil || sess.obj != obj {
 		b.mu.Unlock()
-		if cancel != nil {
-			_ = cancel()
-		}
+		_ = fw.Close()
 		return "session not available"
 	}
 	if sess.fwd == nil {
 		sess.fwd = map[string]*forwardHandle{}
 	}
 	sess.fwdSeq++
-	id := fmt.Sprintf("df-%d", sess.fwdSeq)
-	sess.fwd[id] = &forwardHandle{id: id, mode: "dynamic", from: localSocks, to: "", cancel: cancel}
+	id := fmt.Sprintf("%s-%d", prefix, sess.fwdSeq)
+	sess.fwd[id] = &forwardHandle{id: id, mode: mode, from: from, to: to, socks: socks, cancel: fw.Close, stats: fw.Stats}
 	b.mu.Unlock()
 
 	return ""
@@ -439,52 +585,55 @@ func (b *SSHBridge) StopAllForwards(sessionID string) string {
 	return ""
 }
 
-// KeyPut stores/updates a key by filename with its base64-encoded content.
-// Returns empty string on success; otherwise error text.
-func (b *SSHBridge) KeyPut(filename, pemBase64 string) string {
-	if filename == "" || pemBase64 == "" {
-		return "invalid filename or content"
+// SetProxy configures proxy URL (http/https/socks5), returns error text on failure.
+func (b *SSHBridge) SetProxy(sessionID, v string) string {
+	b.mu.Lock()
+	sess := b.getSessionLocked(sessionID)
+	if sess == nil || sess.obj == nil {
+		b.mu.Unlock()
+		return "ssh object not initialized"
 	}
+	obj := sess.obj
+	b.mu.Unlock()
 
-	b.loadKeys()
-
-	if b.keys == nil {
-		b.keys = map[string]string{}
-	}
-	b.keys[filename] = pemBase64
-	if err := b.saveKeys(); err != nil {
+	if err := sshpkg.SetProxy(obj, v); err != nil {
 		return err.Error()
 	}
 	return ""
 }
 
-// KeyGet returns the base64-encoded content by filename (empty if not present).
-func (b *SSHBridge) KeyGet(filename string) string {
-	if filename == "" {
-		return ""
-	}
-	b.loadKeys()
-	val := b.keys[filename]
-	return val
+// AddJumpWithPasswd appends a ProxyJump hop using username/password auth.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) AddJumpWithPasswd(sessionID, host, user, passwd string) string {
+	hop := sshpkg.InitWithPasswd(host, user, passwd)
+	return b.addJump(sessionID, hop)
 }
 
-// KeyDelete removes a key by filename. Returns empty string on success.
-func (b *SSHBridge) KeyDelete(filename string) string {
-	if filename == "" {
-		return "invalid filename"
+// AddJumpWithAgent appends a ProxyJump hop using ssh-agent auth.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) AddJumpWithAgent(sessionID, host, user string) string {
+	hop, err := sshpkg.InitWithAgent(host, user)
+	if err != nil {
+		return err.Error()
 	}
-	b.loadKeys()
-
-	delete(b.keys, filename)
+	return b.addJump(sessionID, hop)
+}
 
-	if err := b.saveKeys(); err != nil {
+// AddJumpWithPem appends a ProxyJump hop using base64 PEM private key auth.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) AddJumpWithPem(sessionID, host, user, pemBase64 string) string {
+	data, err := base64.StdEncoding.DecodeString(pemBase64)
+	if err != nil {
 		return err.Error()
 	}
-	return ""
+	hop, err := sshpkg.InitWithPem(host, user, data, b.passphrasePrompt(sessionID))
+	if err != nil {
+		return err.Error()
+	}
+	return b.addJump(sessionID, hop)
 }
 
-// SetProxy configures proxy URL (http/https/socks5), returns error text on failure.
-func (b *SSHBridge) SetProxy(sessionID, v string) string {
+func (b *SSHBridge) addJump(sessionID string, hop *sshpkg.Sshobject) string {
 	b.mu.Lock()
 	sess := b.getSessionLocked(sessionID)
 	if sess == nil || sess.obj == nil {
@@ -494,12 +643,23 @@ func (b *SSHBridge) SetProxy(sessionID, v string) string {
 	obj := sess.obj
 	b.mu.Unlock()
 
-	if err := sshpkg.SetProxy(obj, v); err != nil {
+	b.attachPrompts(sessionID, hop)
+	if err := sshpkg.AddJumpHost(obj, hop); err != nil {
 		return err.Error()
 	}
 	return ""
 }
 
+// ClearJumps removes every ProxyJump hop from the session.
+func (b *SSHBridge) ClearJumps(sessionID string) string {
+	obj, err := b.requireSessionObject(sessionID)
+	if err != nil {
+		return err.Error()
+	}
+	sshpkg.ClearJumpHosts(obj)
+	return ""
+}
+
 // CreateClient establishes the SSH connection.
 func (b *SSHBridge) CreateClient(sessionID string) string {
 	b.mu.Lock()
@@ -556,6 +716,22 @@ func (b *SSHBridge) CreateClient(sessionID string) string {
 // 	return ""
 // }
 
+// ConnectWithOptions is Connect with PTY options (TERM, modes, environment,
+// startup command or subsystem). Set fields override the profile's and
+// opts.LoginShell drops its startup command; the result is kept for
+// reconnects and new channels.
+func (b *SSHBridge) ConnectWithOptions(sessionID string, opts sshpkg.PtyOptions) string {
+	b.mu.Lock()
+	sess := b.getSessionLocked(sessionID)
+	if sess == nil || sess.obj == nil {
+		b.mu.Unlock()
+		return "ssh object not initialized"
+	}
+	sess.obj.Pty = sess.obj.Pty.Merge(opts)
+	b.mu.Unlock()
+	return b.Connect(sessionID)
+}
+
 // Connect creates the SSH client and starts the interactive session in one call.
 func (b *SSHBridge) Connect(sessionID string) string {
 	b.mu.Lock()
@@ -564,15 +740,26 @@ func (b *SSHBridge) Connect(sessionID string) string {
 		b.mu.Unlock()
 		return "ssh object not initialized"
 	}
+	if sess.reconnectCancel != nil {
+		sess.reconnectCancel()
+		sess.reconnectCancel = nil
+	}
+	stopRecordingLocked(sess)
 	if sess.ses != nil {
 		_ = sess.ses.Close()
 		sess.ses = nil
 	}
 	obj := sess.obj
+	rows, cols := sess.rows, sess.cols
+	if rows <= 0 || cols <= 0 {
+		rows, cols = 40, 120
+	}
+	defaults := sess.defaultFwd
+	sb := scrollbackLocked(sess)
 	b.mu.Unlock()
 
-	ew := &eventWriter{ctx: b.ctx, sessionID: sessionID}
-	stream, err := sshpkg.ConnectAndStartStream(obj, ew, 40, 120)
+	ew := &eventWriter{ctx: b.ctx, sessionID: sessionID, scrollback: sb}
+	stream, err := sshpkg.ConnectAndStartStream(obj, ew, rows, cols)
 	if err != nil {
 		return err.Error()
 	}
@@ -591,6 +778,8 @@ func (b *SSHBridge) Connect(sessionID string) string {
 	sess.ses = stream
 	b.mu.Unlock()
 
+	b.startDefaultForwards(sessionID, defaults)
+	b.reopenChannels(sessionID, obj)
 	go b.watchSession(sessionID, stream)
 	return ""
 }
@@ -763,28 +952,184 @@ func (b *SSHBridge) watchSession(sessionID string, stream *sshpkg.StreamSession)
 		return
 	}
 	<-stream.Done()
-	runtime.EventsEmit(b.ctx, fmt.Sprintf("ssh:ended:%s", sessionID))
 	b.mu.Lock()
-	defer b.mu.Unlock()
-
 	sess := b.getSessionLocked(sessionID)
-	if sess == nil {
+	if sess == nil || sess.ses != stream {
+		b.mu.Unlock()
+		runtime.EventsEmit(b.ctx, fmt.Sprintf("ssh:ended:%s", sessionID))
 		return
 	}
-	if sess.ses == stream {
-		sess.ses = nil
+	sess.ses = nil
+	obj := sess.obj
+	if obj == nil || obj.R.Disabled || !stream.Dropped() {
+		_ = stopForwardsLocked(sess)
+		stopRecordingLocked(sess)
+		b.mu.Unlock()
+		if obj != nil && stream.Dropped() {
+			b.endChannels(sessionID, obj)
+		}
+		runtime.EventsEmit(b.ctx, fmt.Sprintf("ssh:ended:%s", sessionID))
+		return
 	}
+	fwds := forwardSpecsLocked(sess)
 	_ = stopForwardsLocked(sess)
+	ctx, cancel := context.WithCancel(b.appContext())
+	sess.reconnectCancel = cancel
+	b.mu.Unlock()
+
+	sshpkg.LogErrorf("SSH session %s dropped; reconnecting", sessionID)
+	rows, cols := stream.Size()
+	next, err := b.reconnect(ctx, sessionID, obj, rows, cols)
+	cancel()
+
+	b.mu.Lock()
+	sess = b.getSessionLocked(sessionID)
+	if sess != nil && sess.obj == obj {
+		sess.reconnectCancel = nil
+	}
+	if sess == nil || sess.obj != obj {
+		// Closed or re-initialized while reconnecting.
+		b.mu.Unlock()
+		if next != nil {
+			_ = next.Close()
+		}
+		sshpkg.Close(obj)
+		return
+	}
+	if err != nil {
+		stopRecordingLocked(sess)
+		b.mu.Unlock()
+		if !errors.Is(err, context.Canceled) {
+			b.endChannels(sessionID, obj)
+			sshpkg.LogErrorf("SSH reconnect failed (session=%s): %v", sessionID, err)
+			runtime.EventsEmit(b.ctx, fmt.Sprintf("ssh:ended:%s", sessionID))
+		}
+		return
+	}
+	sess.ses = next
+	next.SetRecorder(sess.rec)
+	monitor := sess.monitor
+	b.mu.Unlock()
+
+	b.startForwards(sessionID, fwds)
+	b.reopenChannels(sessionID, obj)
+	if monitor > 0 {
+		if err := b.startMonitor(sessionID, obj, monitor); err != nil {
+			sshpkg.LogErrorf("Monitor restart failed (session=%s): %v", sessionID, err)
+		}
+	}
+	runtime.EventsEmit(b.ctx, fmt.Sprintf("ssh:reconnected:%s", sessionID))
+	go b.watchSession(sessionID, next)
+}
+
+// ReconnectEvent is emitted on "ssh:reconnecting:<sessionID>" before each
+// reconnect attempt.
+type ReconnectEvent struct {
+	Attempt     int    `json:"attempt"`
+	MaxAttempts int    `json:"maxAttempts"`
+	DelayMs     int64  `json:"delayMs"`
+	Error       string `json:"error,omitempty"`
+}
+
+// reconnect re-establishes the client, SFTP and a shell of the same size.
+func (b *SSHBridge) reconnect(ctx context.Context, sessionID string, obj *sshpkg.Sshobject, rows, cols int) (*sshpkg.StreamSession, error) {
+	notify := func(attempt, max int, delay time.Duration, lastErr error) {
+		ev := ReconnectEvent{Attempt: attempt, MaxAttempts: max, DelayMs: delay.Milliseconds()}
+		if lastErr != nil {
+			ev.Error = lastErr.Error()
+		}
+		runtime.EventsEmit(b.ctx, fmt.Sprintf("ssh:reconnecting:%s", sessionID), ev)
+	}
+	if err := sshpkg.Reconnect(ctx, obj, notify); err != nil {
+		return nil, err
+	}
+	if err := sshpkg.EnsureSFTP(obj); err != nil {
+		sshpkg.LogErrorf("SFTP init failed (session=%s): %v", sessionID, err)
+	}
+	b.mu.Lock()
+	sb := scrollbackLocked(b.getSessionLocked(sessionID))
+	b.mu.Unlock()
+	ew := &eventWriter{ctx: b.ctx, sessionID: sessionID, scrollback: sb}
+	return sshpkg.StartStream(obj, ew, rows, cols)
+}
+
+// forwardSpecsLocked snapshots the session's active forwards so they can be
+// started again after a reconnect.
+func forwardSpecsLocked(sess *sessionState) []forwardHandle {
+	out := make([]forwardHandle, 0, len(sess.fwd))
+	for _, h := range sess.fwd {
+		if h != nil {
+			out = append(out, forwardHandle{mode: h.mode, from: h.from, to: h.to, socks: h.socks})
+		}
+	}
+	return out
+}
+
+// appContext returns the Wails context, or Background before startup.
+func (b *SSHBridge) appContext() context.Context {
+	if b.ctx == nil {
+		return context.Background()
+	}
+	return b.ctx
+}
+
+// SetKeepalive configures keepalive probes for the session's next connect.
+// intervalSec <= 0 disables them; maxMissed <= 0 uses the default.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) SetKeepalive(sessionID string, intervalSec, maxMissed int) string {
+	return b.updateSessionObject(sessionID, func(obj *sshpkg.Sshobject) error {
+		obj.KA = sshpkg.Keepalive{
+			Disabled:  intervalSec <= 0,
+			Interval:  time.Duration(intervalSec) * time.Second,
+			MaxMissed: maxMissed,
+		}
+		return nil
+	})
+}
+
+// SetMultiplex shares the session's connection with every other session to
+// the same user, host and route with the same credentials that has
+// multiplexing on, like OpenSSH ControlMaster. Takes effect on the next
+// connect.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) SetMultiplex(sessionID string, enabled bool) string {
+	return b.updateSessionObject(sessionID, func(obj *sshpkg.Sshobject) error {
+		obj.ShareKey = ""
+		if enabled {
+			obj.ShareKey = sshpkg.Identity(obj)
+		}
+		return nil
+	})
+}
+
+// SetReconnectPolicy configures automatic reconnect for the session.
+// maxAttempts <= 0 disables it; zero delays use the defaults.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) SetReconnectPolicy(sessionID string, maxAttempts, baseDelayMs, maxDelayMs int) string {
+	return b.updateSessionObject(sessionID, func(obj *sshpkg.Sshobject) error {
+		obj.R = sshpkg.Retary{
+			Disabled:    maxAttempts <= 0,
+			MaxAttempts: maxAttempts,
+			BaseDelay:   time.Duration(baseDelayMs) * time.Millisecond,
+			MaxDelay:    time.Duration(maxDelayMs) * time.Millisecond,
+		}
+		return nil
+	})
 }
 
 // eventWriter emits SSH output chunks to the frontend as events.
 type eventWriter struct {
-	ctx       context.Context
-	sessionID string
+	ctx        context.Context
+	sessionID  string
+	scrollback *sshpkg.Scrollback
 }
 
 func (w *eventWriter) Write(p []byte) (int, error) {
-	if w == nil || w.ctx == nil {
+	if w == nil {
+		return len(p), nil
+	}
+	w.scrollback.Write(p)
+	if w.ctx == nil {
 		return len(p), nil
 	}
 	runtime.EventsEmit(w.ctx, fmt.Sprintf("ssh:output:%s", w.sessionID), string(p))
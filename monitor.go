@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	sshpkg "github.com/flyingeirc/erban/internal/ssh"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// StartMonitor samples CPU, memory, disk and network of the remote host
+// every intervalMs and emits each sample on "ssh:metrics:<sessionID>".
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) StartMonitor(sessionID string, intervalMs int) string {
+	obj, err := b.requireSessionObject(sessionID)
+	if err != nil {
+		return err.Error()
+	}
+	interval := time.Duration(intervalMs) * time.Millisecond
+	if err := b.startMonitor(sessionID, obj, interval); err != nil {
+		sshpkg.LogErrorf("Monitor start failed (session=%s): %v", sessionID, err)
+		return err.Error()
+	}
+
+	b.mu.Lock()
+	if sess := b.getSessionLocked(sessionID); sess != nil && sess.obj == obj {
+		sess.monitor = interval
+	}
+	b.mu.Unlock()
+	return ""
+}
+
+// StopMonitor stops the session's metrics sampler.
+func (b *SSHBridge) StopMonitor(sessionID string) string {
+	obj, err := b.requireSessionObject(sessionID)
+	if err != nil {
+		return err.Error()
+	}
+	b.mu.Lock()
+	if sess := b.getSessionLocked(sessionID); sess != nil {
+		sess.monitor = 0
+	}
+	b.mu.Unlock()
+	sshpkg.StopMonitor(obj)
+	return ""
+}
+
+func (b *SSHBridge) startMonitor(sessionID string, obj *sshpkg.Sshobject, interval time.Duration) error {
+	event := fmt.Sprintf("ssh:metrics:%s", sessionID)
+	return sshpkg.StartMonitor(obj, interval, func(m sshpkg.Metrics) {
+		if b.ctx != nil {
+			runtime.EventsEmit(b.ctx, event, m)
+		}
+	})
+}
+
+// ProcessListResult 表示远程进程列表的返回数据
+type ProcessListResult struct {
+	Processes []sshpkg.Process `json:"processes,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// ListProcesses returns the remote process table. sortBy is "cpu", "mem",
+// "rss", "pid", "user" or "command"; filter matches command or user;
+// limit <= 0 returns every process.
+func (b *SSHBridge) ListProcesses(sessionID, sortBy string, asc bool, filter string, limit int) *ProcessListResult {
+	obj, err := b.requireSessionObject(sessionID)
+	if err != nil {
+		return &ProcessListResult{Error: err.Error()}
+	}
+	procs, err := sshpkg.ListProcesses(obj, sshpkg.ProcessQuery{
+		SortBy: sortBy,
+		Asc:    asc,
+		Filter: filter,
+		Limit:  limit,
+	})
+	if err != nil {
+		sshpkg.LogErrorf("Process list failed (session=%s): %v", sessionID, err)
+		return &ProcessListResult{Error: err.Error()}
+	}
+	return &ProcessListResult{Processes: procs}
+}
+
+// KillProcess sends signal ("TERM", "KILL", ... or a number; empty means
+// TERM) to pid on the remote host.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) KillProcess(sessionID string, pid int, signal string) string {
+	obj, err := b.requireSessionObject(sessionID)
+	if err != nil {
+		return err.Error()
+	}
+	if err := sshpkg.KillProcess(obj, pid, signal); err != nil {
+		sshpkg.LogErrorf("Kill failed (session=%s): %v", sessionID, err)
+		return err.Error()
+	}
+	return ""
+}
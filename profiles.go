@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	sshpkg "github.com/flyingeirc/erban/internal/ssh"
+	"github.com/flyingeirc/erban/internal/store"
+)
+
+// ProfileListResult 表示连接配置列表的返回数据
+type ProfileListResult struct {
+	Profiles []store.Profile `json:"profiles,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// ProfileResult 表示单个连接配置的返回数据
+type ProfileResult struct {
+	Profile *store.Profile `json:"profile,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+func (b *SSHBridge) profileStore() *store.ProfileStore {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.profiles == nil {
+		b.profiles = store.NewProfileStore("")
+	}
+	return b.profiles
+}
+
+// ProfileList returns every saved connection profile.
+func (b *SSHBridge) ProfileList() *ProfileListResult {
+	list, err := b.profileStore().List()
+	if err != nil {
+		sshpkg.LogErrorf("Profile list failed: %v", err)
+		return &ProfileListResult{Error: err.Error()}
+	}
+	return &ProfileListResult{Profiles: list}
+}
+
+// ProfileGet returns a saved profile by id.
+func (b *SSHBridge) ProfileGet(id string) *ProfileResult {
+	p, err := b.profileStore().Get(id)
+	if err != nil {
+		return &ProfileResult{Error: err.Error()}
+	}
+	return &ProfileResult{Profile: &p}
+}
+
+// ProfileSave creates (empty id) or updates a profile and returns the stored value.
+func (b *SSHBridge) ProfileSave(p store.Profile) *ProfileResult {
+	saved, err := b.profileStore().Save(p)
+	if err != nil {
+		sshpkg.LogErrorf("Profile save failed: %v", err)
+		return &ProfileResult{Error: err.Error()}
+	}
+	return &ProfileResult{Profile: &saved}
+}
+
+// ProfileDelete removes a profile. Returns empty string on success.
+func (b *SSHBridge) ProfileDelete(id string) string {
+	if err := b.profileStore().Delete(id); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// InitFromProfile initializes the session from a saved profile. passwd is
+// used for the target and any jump host with password auth. The profile's
+// terminal size and default forwards are applied by Connect.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) InitFromProfile(sessionID, profileID, passwd string) string {
+	if sessionID == "" {
+		return "invalid session id"
+	}
+	p, err := b.profileStore().Get(profileID)
+	if err != nil {
+		return err.Error()
+	}
+	obj, err := b.buildProfileObject(sessionID, p, passwd)
+	if err != nil {
+		return err.Error()
+	}
+	b.attachPrompts(sessionID, obj)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sess := b.ensureSessionLocked(sessionID)
+	b.closeSessionLocked(sessionID, sess, false)
+	sess.obj = obj
+	sess.rows, sess.cols = p.Rows, p.Cols
+	sess.defaultFwd = append([]store.Forward(nil), p.Forwards...)
+	return ""
+}
+
+// buildProfileObject creates an unconnected Sshobject from p, including its
+// agent forwarding, reconnect, keepalive, proxy and jump settings.
+func (b *SSHBridge) buildProfileObject(sessionID string, p store.Profile, passwd string) (*sshpkg.Sshobject, error) {
+	obj, err := b.profileObject(sessionID, p.Addr(), p.User, p.Auth, p.KeyRef, p.CertRef, passwd)
+	if err != nil {
+		return nil, err
+	}
+	obj.Label = p.Label
+	if err := sshpkg.SetAgentForwarding(obj, p.ForwardAgent); err != nil {
+		return nil, err
+	}
+	if err := sshpkg.SetX11Forwarding(obj, p.ForwardX11); err != nil {
+		return nil, err
+	}
+	obj.Pty = sshpkg.PtyOptions{
+		Term:      p.Term,
+		Modes:     p.TermModes,
+		Env:       p.Env,
+		Command:   p.StartupCommand,
+		Subsystem: p.Subsystem,
+	}
+	obj.R = sshpkg.Retary{Disabled: p.ReconnectAttempts < 0, MaxAttempts: p.ReconnectAttempts}
+	obj.KA = sshpkg.Keepalive{
+		Disabled:  p.KeepaliveInterval < 0,
+		Interval:  time.Duration(p.KeepaliveInterval) * time.Second,
+		MaxMissed: p.KeepaliveCountMax,
+	}
+	if p.ProxyURL != "" {
+		if err := sshpkg.SetProxy(obj, p.ProxyURL); err != nil {
+			return nil, err
+		}
+	}
+	for _, j := range p.Jumps {
+		hop, err := b.profileObject(sessionID, j.Addr(), j.User, j.Auth, j.KeyRef, j.CertRef, passwd)
+		if err != nil {
+			return nil, fmt.Errorf("jump host %s: %v", j.Host, err)
+		}
+		if err := sshpkg.AddJumpHost(obj, hop); err != nil {
+			return nil, err
+		}
+	}
+	if p.Multiplex {
+		obj.ShareKey = sshpkg.Identity(obj)
+	}
+	return obj, nil
+}
+
+// profileObject builds an Sshobject for one node of a profile. With key or
+// agent auth, passwd is kept for servers that also require a password, and
+// certRef attaches a user certificate to the key.
+func (b *SSHBridge) profileObject(sessionID, addr, user, auth, keyRef, certRef, passwd string) (*sshpkg.Sshobject, error) {
+	var obj *sshpkg.Sshobject
+	switch auth {
+	case store.AuthAgent:
+		o, err := sshpkg.InitWithAgent(addr, user)
+		if err != nil {
+			return nil, err
+		}
+		obj = o
+	case store.AuthKey:
+		enc, err := b.keyStore().Get(keyRef)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", keyRef, err)
+		}
+		data, err := base64.StdEncoding.DecodeString(string(enc))
+		if err != nil {
+			return nil, err
+		}
+		o, err := sshpkg.InitWithPem(addr, user, data, b.passphrasePrompt(sessionID))
+		if err != nil {
+			return nil, err
+		}
+		if certRef != "" {
+			if err := b.attachStoredCert(o, certRef); err != nil {
+				return nil, err
+			}
+		}
+		obj = o
+	default:
+		return sshpkg.InitWithPasswd(addr, user, passwd), nil
+	}
+	if passwd != "" {
+		if err := sshpkg.SetPassword(obj, passwd); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// attachStoredCert attaches the base64 certificate stored under certRef.
+func (b *SSHBridge) attachStoredCert(obj *sshpkg.Sshobject, certRef string) error {
+	enc, err := b.keyStore().Get(certRef)
+	if err != nil {
+		return fmt.Errorf("certificate %q: %w", certRef, err)
+	}
+	data, err := base64.StdEncoding.DecodeString(string(enc))
+	if err != nil {
+		return err
+	}
+	return sshpkg.SetCertificate(obj, data)
+}
+
+// startDefaultForwards starts the profile forwards recorded for a session.
+func (b *SSHBridge) startDefaultForwards(sessionID string, fwds []store.Forward) {
+	specs := make([]forwardHandle, 0, len(fwds))
+	for _, f := range fwds {
+		h := forwardHandle{mode: f.Mode, from: f.From, to: f.To}
+		h.socks = sshpkg.SocksOptions{Username: f.Username, UDPHelper: f.UDPHelper}
+		if f.Username != "" && f.PasswordRef != "" {
+			pass, err := b.keyStore().Get(f.PasswordRef)
+			if err != nil {
+				sshpkg.LogErrorf("Default forward %s %s failed (session=%s): %v", f.Mode, f.From, sessionID, err)
+				continue
+			}
+			h.socks.Password = string(pass)
+		}
+		specs = append(specs, h)
+	}
+	b.startForwards(sessionID, specs)
+}
+
+// startForwards starts each forward spec, logging failures.
+func (b *SSHBridge) startForwards(sessionID string, specs []forwardHandle) {
+	for _, f := range specs {
+		var msg string
+		switch f.mode {
+		case "local":
+			msg = b.StartLocalForward(sessionID, f.from, f.to)
+		case "remote":
+			msg = b.StartRemoteForward(sessionID, f.from, f.to)
+		case "local-unix":
+			msg = b.StartLocalStreamForward(sessionID, f.from, f.to)
+		case "remote-unix":
+			msg = b.StartRemoteStreamForward(sessionID, f.from, f.to)
+		case "dynamic":
+			msg = b.StartDynamicForwardWithOptions(sessionID, f.from, f.socks)
+		case "http":
+			msg = b.StartHTTPProxyForward(sessionID, f.from, f.socks)
+		default:
+			msg = "unknown forward mode " + f.mode
+		}
+		if msg != "" {
+			sshpkg.LogErrorf("Default forward %s %s failed (session=%s): %s", f.mode, f.from, sessionID, msg)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	sshpkg "github.com/flyingeirc/erban/internal/ssh"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// promptTimeout bounds how long a connection waits for the user to answer.
+const promptTimeout = 2 * time.Minute
+
+// promptReply carries the frontend's answer to a pending prompt.
+type promptReply struct {
+	accept  bool
+	text    string
+	answers []string
+}
+
+// HostKeyPromptEvent is emitted on "ssh:hostkey:<sessionID>" when a server
+// presents an unknown host key. Answer with HostKeyResponse(ID, accept).
+type HostKeyPromptEvent struct {
+	ID string `json:"id"`
+	sshpkg.HostKeyInfo
+}
+
+// awaitPrompt registers a pending prompt, emits event with the payload built
+// for its id and blocks until the frontend answers or the prompt times out.
+func (b *SSHBridge) awaitPrompt(event string, payload func(id string) any) (promptReply, bool) {
+	b.mu.Lock()
+	if b.prompts == nil {
+		b.prompts = make(map[string]chan promptReply)
+	}
+	b.promptSeq++
+	id := fmt.Sprintf("p-%d", b.promptSeq)
+	ch := make(chan promptReply, 1)
+	b.prompts[id] = ch
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.prompts, id)
+		b.mu.Unlock()
+	}()
+
+	if b.ctx == nil {
+		return promptReply{}, false
+	}
+	runtime.EventsEmit(b.ctx, event, payload(id))
+
+	select {
+	case r := <-ch:
+		return r, true
+	case <-time.After(promptTimeout):
+		sshpkg.LogErrorf("Prompt %s (%s) timed out", id, event)
+		return promptReply{}, false
+	}
+}
+
+// answerPrompt delivers a reply to a pending prompt.
+func (b *SSHBridge) answerPrompt(id string, r promptReply) string {
+	b.mu.Lock()
+	ch, ok := b.prompts[id]
+	b.mu.Unlock()
+	if !ok {
+		return "prompt not found"
+	}
+	select {
+	case ch <- r:
+	default:
+	}
+	return ""
+}
+
+// hostKeyPrompt returns the trust-on-first-use callback for a session.
+func (b *SSHBridge) hostKeyPrompt(sessionID string) sshpkg.HostKeyPrompt {
+	return func(info sshpkg.HostKeyInfo) bool {
+		r, ok := b.awaitPrompt(fmt.Sprintf("ssh:hostkey:%s", sessionID), func(id string) any {
+			return HostKeyPromptEvent{ID: id, HostKeyInfo: info}
+		})
+		return ok && r.accept
+	}
+}
+
+// HostKeyResponse answers a pending "ssh:hostkey:<sessionID>" prompt.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) HostKeyResponse(promptID string, accept bool) string {
+	return b.answerPrompt(promptID, promptReply{accept: accept})
+}
+
+// PassphrasePromptEvent is emitted on "ssh:passphrase:<sessionID>" when an
+// encrypted private key is loaded. Answer with PassphraseResponse.
+type PassphrasePromptEvent struct {
+	ID string `json:"id"`
+	sshpkg.PassphraseInfo
+}
+
+// passphrasePrompt returns the private key passphrase callback for a session.
+func (b *SSHBridge) passphrasePrompt(sessionID string) sshpkg.PassphrasePrompt {
+	return func(info sshpkg.PassphraseInfo) (string, bool) {
+		r, ok := b.awaitPrompt(fmt.Sprintf("ssh:passphrase:%s", sessionID), func(id string) any {
+			return PassphrasePromptEvent{ID: id, PassphraseInfo: info}
+		})
+		return r.text, ok && r.accept
+	}
+}
+
+// PassphraseResponse answers a pending "ssh:passphrase:<sessionID>" prompt;
+// ok=false cancels loading the key.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) PassphraseResponse(promptID, passphrase string, ok bool) string {
+	return b.answerPrompt(promptID, promptReply{accept: ok, text: passphrase})
+}
+
+// KeyboardPromptEvent is emitted on "ssh:kbdint:<sessionID>" for each
+// keyboard-interactive round (password, one-time code, ...). Answer with
+// KeyboardInteractiveResponse.
+type KeyboardPromptEvent struct {
+	ID string `json:"id"`
+	sshpkg.KeyboardChallenge
+}
+
+// keyboardPrompt returns the keyboard-interactive callback for a session.
+func (b *SSHBridge) keyboardPrompt(sessionID string) sshpkg.KeyboardInteractivePrompt {
+	return func(ch sshpkg.KeyboardChallenge) ([]string, bool) {
+		r, ok := b.awaitPrompt(fmt.Sprintf("ssh:kbdint:%s", sessionID), func(id string) any {
+			return KeyboardPromptEvent{ID: id, KeyboardChallenge: ch}
+		})
+		return r.answers, ok && r.accept
+	}
+}
+
+// KeyboardInteractiveResponse answers a pending "ssh:kbdint:<sessionID>"
+// prompt with one answer per question; ok=false aborts authentication.
+// Returns empty string on success; otherwise error text.
+func (b *SSHBridge) KeyboardInteractiveResponse(promptID string, answers []string, ok bool) string {
+	return b.answerPrompt(promptID, promptReply{accept: ok, answers: answers})
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	sshpkg "github.com/flyingeirc/erban/internal/ssh"
+	"github.com/flyingeirc/erban/internal/store"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// RecordingResult 表示会话录制操作的返回数据
+type RecordingResult struct {
+	Path  string `json:"path,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// RecordingInfo describes one .cast file in the recording directory.
+type RecordingInfo struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// RecordingListResult 表示录制文件列表的返回数据
+type RecordingListResult struct {
+	Recordings []RecordingInfo `json:"recordings,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// PlaybackResizeEvent is emitted on "ssh:playback:resize:<playbackID>" when
+// the recorded terminal changes size.
+type PlaybackResizeEvent struct {
+	Rows int `json:"rows"`
+	Cols int `json:"cols"`
+}
+
+// recordingDir returns the configured recording directory or the default
+// under the app config dir.
+func (b *SSHBridge) recordingDir() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.recordDir != "" {
+		return b.recordDir
+	}
+	return filepath.Join(store.AppDir(), "recordings")
+}
+
+// RecordingDir returns the directory new recordings are written to.
+func (b *SSHBridge) RecordingDir() string { return b.recordingDir() }
+
+// SetRecordingDir changes the directory new recordings are written to.
+// An empty dir restores the default.
+func (b *SSHBridge) SetRecordingDir(dir string) string {
+	dir = strings.TrimSpace(dir)
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err.Error()
+		}
+	}
+	b.mu.Lock()
+	b.recordDir = dir
+	b.mu.Unlock()
+	return ""
+}
+
+// StartRecording records the session's shell output and resizes to a new
+// asciicast v2 file. Recording continues across automatic reconnects.
+func (b *SSHBridge) StartRecording(sessionID string) *RecordingResult {
+	dir := b.recordingDir()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sess := b.getSessionLocked(sessionID)
+	if sess == nil || sess.ses == nil || sess.obj == nil {
+		return &RecordingResult{Error: "session not started"}
+	}
+	if sess.rec != nil {
+		return &RecordingResult{Path: sess.rec.Path(), Error: "already recording"}
+	}
+	title := sess.obj.Label
+	if title == "" {
+		title = sess.obj.User + "@" + sess.obj.Host
+	}
+	name := fmt.Sprintf("%s-%s.cast", recordingName(title), time.Now().Format("20060102-150405"))
+	rows, cols := sess.ses.Size()
+	rec, err := sshpkg.NewRecorder(filepath.Join(dir, name), rows, cols, title, sess.obj.Pty.Term)
+	if err != nil {
+		sshpkg.LogErrorf("Recording start failed (session=%s): %v", sessionID, err)
+		return &RecordingResult{Error: err.Error()}
+	}
+	sess.rec = rec
+	sess.ses.SetRecorder(rec)
+	return &RecordingResult{Path: rec.Path()}
+}
+
+// StopRecording ends the session's recording and returns the file path.
+func (b *SSHBridge) StopRecording(sessionID string) *RecordingResult {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sess := b.getSessionLocked(sessionID)
+	if sess == nil || sess.rec == nil {
+		return &RecordingResult{Error: "not recording"}
+	}
+	path := sess.rec.Path()
+	if err := stopRecordingLocked(sess); err != nil {
+		return &RecordingResult{Path: path, Error: err.Error()}
+	}
+	return &RecordingResult{Path: path}
+}
+
+// stopRecordingLocked detaches and closes the session's recorder.
+func stopRecordingLocked(sess *sessionState) error {
+	if sess == nil || sess.rec == nil {
+		return nil
+	}
+	if sess.ses != nil {
+		sess.ses.SetRecorder(nil)
+	}
+	err := sess.rec.Close()
+	sess.rec = nil
+	return err
+}
+
+// ListRecordings returns the .cast files in the recording directory, newest
+// first.
+func (b *SSHBridge) ListRecordings() *RecordingListResult {
+	dir := b.recordingDir()
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return &RecordingListResult{}
+	}
+	if err != nil {
+		return &RecordingListResult{Error: err.Error()}
+	}
+	var list []RecordingInfo
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".cast") {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		list = append(list, RecordingInfo{
+			Name:    e.Name(),
+			Path:    filepath.Join(dir, e.Name()),
+			Size:    fi.Size(),
+			ModTime: fi.ModTime(),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ModTime.After(list[j].ModTime) })
+	return &RecordingListResult{Recordings: list}
+}
+
+// PlayRecording replays a .cast file on "ssh:output:<playbackID>", the same
+// channel a live terminal uses, with size changes on
+// "ssh:playback:resize:<playbackID>" and "ssh:playback:ended:<playbackID>"
+// (carrying error text or "") at the end. speed <= 0 plays in real time;
+// idle pauses are capped at maxIdleMs when it is > 0.
+func (b *SSHBridge) PlayRecording(playbackID, path string, speed float64, maxIdleMs int) string {
+	if playbackID == "" {
+		return "playback id required"
+	}
+	if _, err := os.Stat(path); err != nil {
+		return err.Error()
+	}
+	ctx, cancel := context.WithCancel(b.appContext())
+	b.mu.Lock()
+	if _, busy := b.playbacks[playbackID]; busy {
+		b.mu.Unlock()
+		cancel()
+		return "playback already running"
+	}
+	if b.playbacks == nil {
+		b.playbacks = make(map[string]context.CancelFunc)
+	}
+	b.playbacks[playbackID] = cancel
+	b.mu.Unlock()
+
+	go func() {
+		defer func() {
+			b.mu.Lock()
+			delete(b.playbacks, playbackID)
+			b.mu.Unlock()
+			cancel()
+		}()
+		ew := &eventWriter{ctx: b.ctx, sessionID: playbackID}
+		resize := func(rows, cols int) {
+			if b.ctx != nil {
+				runtime.EventsEmit(b.ctx, fmt.Sprintf("ssh:playback:resize:%s", playbackID), PlaybackResizeEvent{Rows: rows, Cols: cols})
+			}
+		}
+		maxIdle := time.Duration(maxIdleMs) * time.Millisecond
+		msg := ""
+		if _, err := sshpkg.PlayCast(ctx, path, speed, maxIdle, ew, resize); err != nil && !errors.Is(err, context.Canceled) {
+			sshpkg.LogErrorf("Playback %s failed: %v", path, err)
+			msg = err.Error()
+		}
+		if b.ctx != nil {
+			runtime.EventsEmit(b.ctx, fmt.Sprintf("ssh:playback:ended:%s", playbackID), msg)
+		}
+	}()
+	return ""
+}
+
+// StopPlayback stops a running PlayRecording.
+func (b *SSHBridge) StopPlayback(playbackID string) string {
+	b.mu.Lock()
+	cancel := b.playbacks[playbackID]
+	b.mu.Unlock()
+	if cancel == nil {
+		return "playback not running"
+	}
+	cancel()
+	return ""
+}
+
+// recordingName turns a session title into a safe file name component.
+func recordingName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.', r == '@':
+			return r
+		}
+		return '_'
+	}, title)
+	if name == "" {
+		name = "session"
+	}
+	return name
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+
+	sshpkg "github.com/flyingeirc/erban/internal/ssh"
+)
+
+// ScrollbackResult 表示会话回滚缓冲区的返回数据
+type ScrollbackResult struct {
+	Page  *sshpkg.ScrollbackPage `json:"page,omitempty"`
+	Error string                 `json:"error,omitempty"`
+}
+
+// TranscriptSearchResult 表示会话记录搜索的返回数据
+type TranscriptSearchResult struct {
+	Matches []sshpkg.TranscriptMatch `json:"matches,omitempty"`
+	Error   string                   `json:"error,omitempty"`
+}
+
+// TranscriptExportResult 表示会话记录导出的返回数据
+type TranscriptExportResult struct {
+	Content string `json:"content,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// scrollbackLocked returns the session's scrollback, creating it on first use.
+func scrollbackLocked(sess *sessionState) *sshpkg.Scrollback {
+	if sess == nil {
+		return nil
+	}
+	if sess.scrollback == nil {
+		sess.scrollback = sshpkg.NewScrollback(0)
+	}
+	return sess.scrollback
+}
+
+func (b *SSHBridge) sessionScrollback(sessionID string) *sshpkg.Scrollback {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sess := b.getSessionLocked(sessionID)
+	if sess == nil {
+		return nil
+	}
+	return sess.scrollback
+}
+
+// GetScrollback returns up to limit lines of the session's output history
+// starting at absolute line offset; a negative offset counts back from the
+// newest line. Each line carries the raw output for replaying into the
+// terminal and its plain text.
+func (b *SSHBridge) GetScrollback(sessionID string, offset, limit int) *ScrollbackResult {
+	sb := b.sessionScrollback(sessionID)
+	if sb == nil {
+		return &ScrollbackResult{Error: "no scrollback for session"}
+	}
+	page := sb.Page(offset, limit)
+	return &ScrollbackResult{Page: &page}
+}
+
+// SearchTranscript returns the scrollback lines whose plain text matches the
+// regular expression pattern.
+func (b *SSHBridge) SearchTranscript(sessionID, pattern string) *TranscriptSearchResult {
+	sb := b.sessionScrollback(sessionID)
+	if sb == nil {
+		return &TranscriptSearchResult{Error: "no scrollback for session"}
+	}
+	matches, err := sb.Search(pattern)
+	if err != nil {
+		return &TranscriptSearchResult{Error: err.Error()}
+	}
+	return &TranscriptSearchResult{Matches: matches}
+}
+
+// ExportTranscript renders the scrollback as "text" (default) or "html".
+func (b *SSHBridge) ExportTranscript(sessionID, format string) *TranscriptExportResult {
+	b.mu.Lock()
+	sess := b.getSessionLocked(sessionID)
+	var sb *sshpkg.Scrollback
+	title := sessionID
+	if sess != nil {
+		sb = sess.scrollback
+		if sess.obj != nil {
+			title = sess.obj.User + "@" + sess.obj.Host
+			if sess.obj.Label != "" {
+				title = sess.obj.Label
+			}
+		}
+	}
+	b.mu.Unlock()
+	if sb == nil {
+		return &TranscriptExportResult{Error: "no scrollback for session"}
+	}
+
+	switch strings.ToLower(format) {
+	case "", "text", "txt":
+		return &TranscriptExportResult{Content: sb.Text()}
+	case "html":
+		return &TranscriptExportResult{Content: sb.HTML(title)}
+	default:
+		return &TranscriptExportResult{Error: "unsupported format " + format}
+	}
+}